@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"reflect"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// runCompareABIs implements the compare-abis subcommand: decode the same
+// fetched logs with an old and a new ABI side by side and report which
+// events decode under each and where the decoded fields differ. Run it
+// before switching the tracker to an upgraded contract ABI; it never
+// touches the database.
+func runCompareABIs(args []string) {
+	fs := flag.NewFlagSet("compare-abis", flag.ExitOnError)
+	oldPath := fs.String("old", "", "path to the currently deployed ABI JSON")
+	newPath := fs.String("new", "", "path to the candidate ABI JSON")
+	from := fs.Uint64("from", 0, "first block of the range to compare")
+	to := fs.Uint64("to", 0, "last block of the range to compare")
+	fs.Parse(args)
+	if *oldPath == "" || *newPath == "" || *to < *from {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	oldABI := loadABIFile(*oldPath)
+	newABI := loadABIFile(*newPath)
+
+	client, err := dialEthEndpoint(os.Getenv("ETHEREUM_NODE_URL"))
+	if err != nil {
+		log.Fatalf("Failed to connect to Ethereum node: %v", err)
+	}
+	contractAddress := common.HexToAddress(os.Getenv("WBTC_ADDRESS"))
+
+	var total, oldOnly, newOnly, neither, both, differing int
+	const maxExamples = 5
+	examples := 0
+	for start := *from; start <= *to; start += 100 {
+		end := start + 99
+		if end > *to {
+			end = *to
+		}
+		query := buildFilterQuery(contractAddress, start, end)
+		query.Topics = nil
+		logs, err := client.FilterLogs(context.Background(), query)
+		if err != nil {
+			log.Fatalf("Failed to filter logs %d-%d: %v", start, end, err)
+		}
+		for _, vLog := range logs {
+			total++
+			oldName, oldFields, oldErr := decodeWithABI(oldABI, vLog)
+			newName, newFields, newErr := decodeWithABI(newABI, vLog)
+			switch {
+			case oldErr == nil && newErr != nil:
+				oldOnly++
+				if examples < maxExamples {
+					fmt.Printf("OLD-ONLY %s:%d event=%s new: %v\n", vLog.TxHash.Hex(), vLog.Index, oldName, newErr)
+					examples++
+				}
+			case oldErr != nil && newErr == nil:
+				newOnly++
+				if examples < maxExamples {
+					fmt.Printf("NEW-ONLY %s:%d event=%s old: %v\n", vLog.TxHash.Hex(), vLog.Index, newName, oldErr)
+					examples++
+				}
+			case oldErr != nil && newErr != nil:
+				neither++
+			default:
+				both++
+				if oldName != newName || !reflect.DeepEqual(oldFields, newFields) {
+					differing++
+					if examples < maxExamples {
+						fmt.Printf("DIFFERS %s:%d old=%s%v new=%s%v\n",
+							vLog.TxHash.Hex(), vLog.Index, oldName, oldFields, newName, newFields)
+						examples++
+					}
+				}
+			}
+		}
+	}
+
+	fmt.Printf("compare-abis %d-%d: %d logs, both=%d (differing=%d) old-only=%d new-only=%d neither=%d\n",
+		*from, *to, total, both, differing, oldOnly, newOnly, neither)
+	if differing > 0 || oldOnly > 0 {
+		os.Exit(1)
+	}
+}
+
+func loadABIFile(path string) abi.ABI {
+	file, err := os.Open(path)
+	if err != nil {
+		log.Fatalf("Failed to open ABI %s: %v", path, err)
+	}
+	defer file.Close()
+	parsed, err := abi.JSON(file)
+	if err != nil {
+		log.Fatalf("Failed to parse ABI %s: %v", path, err)
+	}
+	return parsed
+}
+
+// decodeWithABI decodes one log against an arbitrary ABI: event lookup by
+// topic 0, topic-count validation, then the non-indexed data into a map.
+func decodeWithABI(contract abi.ABI, vLog types.Log) (string, map[string]interface{}, error) {
+	if len(vLog.Topics) == 0 {
+		return "", nil, fmt.Errorf("log has no topics")
+	}
+	event, err := contract.EventByID(vLog.Topics[0])
+	if err != nil {
+		return "", nil, err
+	}
+	indexed := 0
+	for _, input := range event.Inputs {
+		if input.Indexed {
+			indexed++
+		}
+	}
+	if len(vLog.Topics) != indexed+1 {
+		return event.Name, nil, fmt.Errorf("topic count %d does not match %d indexed parameters", len(vLog.Topics), indexed)
+	}
+	fields := map[string]interface{}{}
+	if err := contract.UnpackIntoMap(fields, event.Name, vLog.Data); err != nil {
+		return event.Name, nil, err
+	}
+	return event.Name, fields, nil
+}
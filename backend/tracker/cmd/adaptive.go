@@ -0,0 +1,77 @@
+package main
+
+import (
+	"log"
+	"strings"
+)
+
+// Adaptive FilterLogs window. A fixed window is wrong in both directions: a
+// quiet contract wastes round-trips fetching 100 empty blocks at a time,
+// while a busy range trips providers that cap log results per query. The
+// window starts at RPC_WINDOW and doubles after sparse responses up to
+// RPC_WINDOW_MAX; a dense response or a provider result-cap error halves it
+// down to RPC_WINDOW_MIN, and the capped query is retried immediately with
+// the smaller window instead of being treated as an outage.
+type adaptiveWindow struct {
+	current, min, max uint64
+}
+
+func newAdaptiveWindow() *adaptiveWindow {
+	w := &adaptiveWindow{
+		current: envUint("RPC_WINDOW", 100),
+		min:     envUint("RPC_WINDOW_MIN", 10),
+		max:     envUint("RPC_WINDOW_MAX", 2000),
+	}
+	if w.min == 0 {
+		w.min = 1
+	}
+	if w.current < w.min {
+		w.current = w.min
+	}
+	if w.current > w.max {
+		w.current = w.max
+	}
+	return w
+}
+
+func (w *adaptiveWindow) size() uint64 {
+	return w.current
+}
+
+// observe adjusts the window from a successful query's log count.
+func (w *adaptiveWindow) observe(logCount int) {
+	switch {
+	case logCount < 200 && w.current < w.max:
+		w.current *= 2
+		if w.current > w.max {
+			w.current = w.max
+		}
+	case logCount > 5000 && w.current > w.min:
+		w.halve()
+	}
+}
+
+// shrinkOnError reports whether the error is a provider result cap, halving
+// the window so the caller can retry the same range immediately. Anything
+// else (network, auth, outage) is not ours to absorb and returns false.
+func (w *adaptiveWindow) shrinkOnError(err error) bool {
+	message := strings.ToLower(err.Error())
+	capped := strings.Contains(message, "more than") ||
+		strings.Contains(message, "too many results") ||
+		strings.Contains(message, "response size") ||
+		strings.Contains(message, "limit exceeded") ||
+		strings.Contains(message, "block range")
+	if !capped || w.current <= w.min {
+		return false
+	}
+	w.halve()
+	log.Printf("Provider capped the log query (%v); retrying with a %d-block window", err, w.current)
+	return true
+}
+
+func (w *adaptiveWindow) halve() {
+	w.current /= 2
+	if w.current < w.min {
+		w.current = w.min
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"math/big"
+	"net/http"
+	"strconv"
+)
+
+// Alongside the block number checkpoint the tracker stores the hash of the
+// last processed block (written by recordProcessedRange in reorg.go).
+// Comparing that stored hash against the canonical chain tells an operator
+// whether the indexer's position is still on the canonical chain or has been
+// orphaned by a reorg.
+
+// loadProcessedHash returns the stored hash of the checkpoint block, or ""
+// when none has been recorded yet.
+func loadProcessedHash() string {
+	var value string
+	err := db.QueryRow(q("SELECT value FROM tracker_state WHERE key = ?"), "last_processed_hash").Scan(&value)
+	if err == sql.ErrNoRows {
+		return ""
+	}
+	if err != nil {
+		log.Printf("Failed to load checkpoint hash: %v", err)
+		return ""
+	}
+	return value
+}
+
+// handleAdminCheckpoint serves GET /admin/checkpoint: the current checkpoint,
+// its stored block hash, the chain head, and whether the stored hash still
+// matches the canonical chain at that height. A single call tells an operator
+// whether the indexer's position is valid or orphaned.
+func handleAdminCheckpoint(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var checkpoint uint64
+	var value string
+	err := readDB.QueryRow(q("SELECT value FROM tracker_state WHERE key = ?"), "last_processed_block").Scan(&value)
+	if err != nil && err != sql.ErrNoRows {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err == nil {
+		checkpoint, _ = strconv.ParseUint(value, 10, 64)
+	}
+
+	latest, err := currentEthClient().BlockNumber(context.Background())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	storedHash := loadProcessedHash()
+	canonicalHash := ""
+	if checkpoint > 0 {
+		header, err := currentEthClient().HeaderByNumber(context.Background(), new(big.Int).SetUint64(checkpoint))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		canonicalHash = header.Hash().Hex()
+	}
+
+	writeJSON(w, map[string]interface{}{
+		"last_processed_block": checkpoint,
+		"stored_hash":          storedHash,
+		"latest_block":         latest,
+		"canonical_hash":       canonicalHash,
+		"canonical":            storedHash != "" && storedHash == canonicalHash,
+	})
+}
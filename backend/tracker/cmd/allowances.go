@@ -0,0 +1,108 @@
+package main
+
+import (
+	"log"
+	"math/big"
+	"net/http"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// Approval indexing (EVENTS=...,approval). Every Approval sets the absolute
+// allowance for an (owner, spender) pair, so the table keeps only the latest
+// value per pair; an approval of zero revokes and deletes the row. The result
+// is the set of currently open allowances on the token — which contracts can
+// move whose WBTC — served via GET /allowances for security monitoring.
+//
+// The amount column is always TEXT, regardless of the balance schema:
+// allowances are routinely the infinite 2^256-1 and nothing does arithmetic
+// on them, so there is no numeric-mode upside to matching.
+func handleApprovalEvent(vLog types.Log) {
+	if len(vLog.Topics) < 3 {
+		recordDecodeFailure("Approval", vLog)
+		log.Printf("Skipping Approval log with %d topics (tx %s, index %d)", len(vLog.Topics), vLog.TxHash.Hex(), vLog.Index)
+		return
+	}
+	var approvalEvent struct {
+		Value *big.Int
+	}
+	if err := decodeEvent("Approval", &approvalEvent, vLog); err != nil {
+		recordDecodeFailure("Approval", vLog)
+		log.Printf("Skipping undecodable Approval log (tx %s, index %d): %v", vLog.TxHash.Hex(), vLog.Index, err)
+		return
+	}
+	owner := common.HexToAddress(vLog.Topics[1].Hex()).Hex()
+	spender := common.HexToAddress(vLog.Topics[2].Hex()).Hex()
+
+	if approvalEvent.Value.Sign() == 0 {
+		if _, err := db.Exec(q(`DELETE FROM allowances WHERE owner = ? AND spender = ?`), owner, spender); err != nil {
+			log.Printf("Failed to revoke allowance %s -> %s: %v", owner, spender, err)
+		}
+		return
+	}
+	_, err := db.Exec(q(`INSERT INTO allowances (owner, spender, amount, block) VALUES (?, ?, ?, ?)
+		ON CONFLICT(owner, spender) DO UPDATE SET amount = excluded.amount, block = excluded.block`),
+		owner, spender, approvalEvent.Value.String(), vLog.BlockNumber)
+	if err != nil {
+		log.Printf("Failed to record allowance %s -> %s: %v", owner, spender, err)
+	}
+}
+
+// handleAllowances serves GET /allowances?owner=&spender=&limit=: the open
+// allowances, most recently set first, optionally filtered by either side.
+func handleAllowances(w http.ResponseWriter, r *http.Request) {
+	limit := envQueryUint(r, "limit", 100)
+	if limit > 1000 {
+		limit = 1000
+	}
+
+	query := `SELECT owner, spender, amount, block FROM allowances`
+	args := []interface{}{}
+	conditions := []string{}
+	if owner := r.URL.Query().Get("owner"); owner != "" {
+		conditions = append(conditions, "owner = ?")
+		args = append(args, normalizeAddressParam(owner))
+	}
+	if spender := r.URL.Query().Get("spender"); spender != "" {
+		conditions = append(conditions, "spender = ?")
+		args = append(args, normalizeAddressParam(spender))
+	}
+	for i, condition := range conditions {
+		if i == 0 {
+			query += " WHERE " + condition
+		} else {
+			query += " AND " + condition
+		}
+	}
+	query += " ORDER BY block DESC LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := readDB.Query(q(query), args...)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	type allowance struct {
+		Owner   string `json:"owner"`
+		Spender string `json:"spender"`
+		Amount  string `json:"amount"`
+		Block   uint64 `json:"block"`
+	}
+	allowances := []allowance{}
+	for rows.Next() {
+		var a allowance
+		if err := rows.Scan(&a.Owner, &a.Spender, &a.Amount, &a.Block); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		allowances = append(allowances, a)
+	}
+	if err := rows.Err(); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, allowances)
+}
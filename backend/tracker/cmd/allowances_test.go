@@ -0,0 +1,66 @@
+package main
+
+import (
+	"database/sql"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func makeApprovalLog(t *testing.T, owner, spender string, value *big.Int, block uint64, tx string, index uint) types.Log {
+	t.Helper()
+	return types.Log{
+		Address: common.HexToAddress("0x00000000000000000000000000000000000000aa"),
+		Topics: []common.Hash{
+			contractABI.Events["Approval"].ID,
+			common.HexToHash(owner),
+			common.HexToHash(spender),
+		},
+		Data:        common.BigToHash(value).Bytes(),
+		BlockNumber: block,
+		TxHash:      common.HexToHash(tx),
+		Index:       index,
+	}
+}
+
+func TestHandleApprovalEventLatestWinsAndZeroRevokes(t *testing.T) {
+	initTestABI(t)
+	setupTestDB(t)
+	const (
+		owner   = "0x0000000000000000000000000000000000000aaa"
+		spender = "0x0000000000000000000000000000000000000bbb"
+	)
+
+	// An infinite allowance must store exactly.
+	infinite := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1))
+	handleApprovalEvent(makeApprovalLog(t, owner, spender, infinite, 1, "0x01", 0))
+	var amount string
+	err := db.QueryRow(q(`SELECT amount FROM allowances WHERE owner = ? AND spender = ?`),
+		common.HexToAddress(owner).Hex(), common.HexToAddress(spender).Hex()).Scan(&amount)
+	if err != nil {
+		t.Fatalf("read allowance: %v", err)
+	}
+	if amount != infinite.String() {
+		t.Errorf("allowance = %s, want %s", amount, infinite)
+	}
+
+	// A later approval replaces, it does not accumulate.
+	handleApprovalEvent(makeApprovalLog(t, owner, spender, big.NewInt(500), 2, "0x02", 0))
+	if err := db.QueryRow(q(`SELECT amount FROM allowances WHERE owner = ? AND spender = ?`),
+		common.HexToAddress(owner).Hex(), common.HexToAddress(spender).Hex()).Scan(&amount); err != nil {
+		t.Fatalf("read allowance: %v", err)
+	}
+	if amount != "500" {
+		t.Errorf("allowance after re-approval = %s, want 500", amount)
+	}
+
+	// Approving zero revokes the pair entirely.
+	handleApprovalEvent(makeApprovalLog(t, owner, spender, big.NewInt(0), 3, "0x03", 0))
+	err = db.QueryRow(q(`SELECT amount FROM allowances WHERE owner = ? AND spender = ?`),
+		common.HexToAddress(owner).Hex(), common.HexToAddress(spender).Hex()).Scan(&amount)
+	if err != sql.ErrNoRows {
+		t.Errorf("revoked allowance still present (err = %v)", err)
+	}
+}
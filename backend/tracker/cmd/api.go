@@ -0,0 +1,98 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var (
+	batchLimit       uint64
+	batchOmitUnknown bool
+
+	// minHolderBalance (HOLDER_MIN_BALANCE) excludes dust addresses from the
+	// holder-count metric and the default listing. It only affects what is
+	// reported: every balance is still stored and any address remains
+	// queryable directly. 0 keeps the historical any-nonzero behavior.
+	minHolderBalance int64
+)
+
+// normalizeAddressParam turns a user-supplied address path segment into the
+// checksummed form the database stores; invalid input is passed through and
+// simply matches nothing.
+func normalizeAddressParam(address string) string {
+	if common.IsHexAddress(address) {
+		return common.HexToAddress(address).Hex()
+	}
+	return address
+}
+
+func initAPI() {
+	batchLimit = envUint("BATCH_LIMIT", 500)
+	batchOmitUnknown = os.Getenv("BATCH_OMIT_UNKNOWN") == "true"
+	minHolderBalance = int64(envUint("HOLDER_MIN_BALANCE", 0))
+}
+
+// handleHoldersBatch serves POST /holders/batch with a JSON array of
+// addresses and returns a map of normalized address to balance. Addresses
+// the tracker has never seen come back as 0, or are omitted entirely when
+// BATCH_OMIT_UNKNOWN is set. The lookup is a single WHERE IN query.
+func handleHoldersBatch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var raw []string
+	if err := json.NewDecoder(r.Body).Decode(&raw); err != nil {
+		http.Error(w, "body must be a JSON array of addresses", http.StatusBadRequest)
+		return
+	}
+	if uint64(len(raw)) > batchLimit {
+		http.Error(w, "too many addresses in one batch", http.StatusBadRequest)
+		return
+	}
+
+	addresses := make([]string, 0, len(raw))
+	for _, a := range raw {
+		if !common.IsHexAddress(a) {
+			http.Error(w, "invalid address: "+a, http.StatusBadRequest)
+			return
+		}
+		addresses = append(addresses, common.HexToAddress(a).Hex())
+	}
+
+	result := make(map[string]string, len(addresses))
+	if len(addresses) > 0 {
+		placeholders := strings.TrimSuffix(strings.Repeat("?,", len(addresses)), ",")
+		args := make([]interface{}, len(addresses))
+		for i, a := range addresses {
+			args[i] = a
+		}
+		rows, err := readDB.Query(q("SELECT address, balance FROM wbtc_holders WHERE address IN ("+placeholders+")"), args...)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var address, balance string
+			if err := rows.Scan(&address, &balance); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			result[address] = balance
+		}
+	}
+	if !batchOmitUnknown {
+		for _, a := range addresses {
+			if _, ok := result[a]; !ok {
+				result[a] = "0"
+			}
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"math/big"
+	"os"
+	"strconv"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// bootstrapState is a shippable known-good starting point: a checkpoint
+// block plus the full holder set at that block, computed offline. New
+// deployments load it instead of backfilling from zero. Balances decode as
+// json.Number so uint256 values survive the round trip without float
+// rounding; each is validated as a decimal integer before insert.
+type bootstrapState struct {
+	Token    string                 `json:"token"`
+	Block    uint64                 `json:"block"`
+	Balances map[string]json.Number `json:"balances"`
+}
+
+// maybeLoadBootstrapState seeds an empty database from the BOOTSTRAP_STATE
+// file, setting the checkpoint so live indexing resumes right after the
+// bootstrap block. A database that already has holders is left untouched.
+func maybeLoadBootstrapState() {
+	path := os.Getenv("BOOTSTRAP_STATE")
+	if path == "" {
+		return
+	}
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM wbtc_holders").Scan(&count); err != nil {
+		log.Fatalf("Failed to check wbtc_holders before bootstrap: %v", err)
+	}
+	if count > 0 {
+		log.Printf("Skipping bootstrap state %s: wbtc_holders already has %d rows", path, count)
+		return
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		log.Fatalf("Failed to read bootstrap state: %v", err)
+	}
+	var state bootstrapState
+	if err := json.Unmarshal(raw, &state); err != nil {
+		log.Fatalf("Failed to parse bootstrap state %s: %v", path, err)
+	}
+
+	configured := common.HexToAddress(os.Getenv("WBTC_ADDRESS"))
+	if common.HexToAddress(state.Token) != configured {
+		log.Fatalf("Bootstrap state is for token %s but tracker is configured for %s", state.Token, configured.Hex())
+	}
+	if state.Block == 0 {
+		log.Fatalf("Bootstrap state %s has no block number", path)
+	}
+	if start := os.Getenv("STARTING_BLOCK"); start != "" {
+		if s, err := strconv.ParseUint(start, 10, 64); err == nil && state.Block < s {
+			log.Fatalf("Bootstrap state block %d is before STARTING_BLOCK %d", state.Block, s)
+		}
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		log.Fatalf("Failed to begin bootstrap transaction: %v", err)
+	}
+	for address, balance := range state.Balances {
+		parsed, ok := new(big.Int).SetString(balance.String(), 10)
+		if !ok {
+			tx.Rollback()
+			log.Fatalf("Bootstrap balance %q for %s is not a decimal integer", balance.String(), address)
+		}
+		if _, err := tx.Exec(q("INSERT INTO wbtc_holders (address, balance) VALUES (?, ?)"),
+			common.HexToAddress(address).Hex(), parsed.String()); err != nil {
+			tx.Rollback()
+			log.Fatalf("Failed to load bootstrap balance for %s: %v", address, err)
+		}
+	}
+	if _, err := tx.Exec(q(`INSERT INTO tracker_state (key, value) VALUES (?, ?)
+		ON CONFLICT(key) DO UPDATE SET value = excluded.value`),
+		"last_processed_block", strconv.FormatUint(state.Block, 10)); err != nil {
+		tx.Rollback()
+		log.Fatalf("Failed to set bootstrap checkpoint: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		log.Fatalf("Failed to commit bootstrap state: %v", err)
+	}
+	log.Printf("Loaded bootstrap state %s: %d holders at block %d", path, len(state.Balances), state.Block)
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"crypto/sha256"
+	"math/big"
+	"strings"
+)
+
+// BTC payout address validation. The payout service pays whatever address the
+// redemption row carries, so a burn whose data payload merely looks like text
+// must not reach it: the address has to carry a verifiable checksum. Both
+// encodings in use are covered — base58check (legacy 1…/3… and testnet
+// m/n/2…) and bech32/bech32m (bc1…/tb1…/bcrt1… segwit).
+
+// validBTCAddress reports whether the string is a checksummed bitcoin
+// address in either encoding.
+func validBTCAddress(address string) bool {
+	lower := strings.ToLower(address)
+	for _, hrp := range []string{"bc1", "tb1", "bcrt1"} {
+		if strings.HasPrefix(lower, hrp) {
+			return validBech32Address(address)
+		}
+	}
+	return validBase58Address(address)
+}
+
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+// validBase58Address checks a base58check address: 25 decoded bytes whose
+// trailing 4 are the double-SHA256 checksum of the rest.
+func validBase58Address(address string) bool {
+	if len(address) < 26 || len(address) > 35 {
+		return false
+	}
+	value := new(big.Int)
+	radix := big.NewInt(58)
+	for _, r := range address {
+		index := strings.IndexRune(base58Alphabet, r)
+		if index < 0 {
+			return false
+		}
+		value.Mul(value, radix)
+		value.Add(value, big.NewInt(int64(index)))
+	}
+	decoded := value.Bytes()
+	// Leading '1' characters encode leading zero bytes.
+	leadingZeros := 0
+	for _, r := range address {
+		if r != '1' {
+			break
+		}
+		leadingZeros++
+	}
+	payload := make([]byte, leadingZeros+len(decoded))
+	copy(payload[leadingZeros:], decoded)
+	if len(payload) != 25 {
+		return false
+	}
+	first := sha256.Sum256(payload[:21])
+	second := sha256.Sum256(first[:])
+	for i := 0; i < 4; i++ {
+		if payload[21+i] != second[i] {
+			return false
+		}
+	}
+	return true
+}
+
+const bech32Charset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+// bech32Polymod is the checksum function from BIP-173.
+func bech32Polymod(values []byte) uint32 {
+	generator := []uint32{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
+	chk := uint32(1)
+	for _, v := range values {
+		top := chk >> 25
+		chk = (chk&0x1ffffff)<<5 ^ uint32(v)
+		for i := 0; i < 5; i++ {
+			if (top>>uint(i))&1 == 1 {
+				chk ^= generator[i]
+			}
+		}
+	}
+	return chk
+}
+
+// validBech32Address checks a segwit address: charset, case, checksum
+// (bech32 for witness v0, bech32m for v1+ per BIP-350), and witness program
+// length.
+func validBech32Address(address string) bool {
+	if address != strings.ToLower(address) && address != strings.ToUpper(address) {
+		return false
+	}
+	lower := strings.ToLower(address)
+	sep := strings.LastIndexByte(lower, '1')
+	if sep < 1 || sep+7 > len(lower) || len(lower) > 90 {
+		return false
+	}
+	hrp := lower[:sep]
+	switch hrp {
+	case "bc", "tb", "bcrt":
+	default:
+		return false
+	}
+
+	data := make([]byte, 0, len(lower)-sep-1)
+	for _, r := range lower[sep+1:] {
+		index := strings.IndexRune(bech32Charset, r)
+		if index < 0 {
+			return false
+		}
+		data = append(data, byte(index))
+	}
+
+	values := make([]byte, 0, len(hrp)*2+1+len(data))
+	for _, r := range hrp {
+		values = append(values, byte(r)>>5)
+	}
+	values = append(values, 0)
+	for _, r := range hrp {
+		values = append(values, byte(r)&31)
+	}
+	values = append(values, data...)
+
+	version := data[0]
+	if version > 16 {
+		return false
+	}
+	checksum := bech32Polymod(values)
+	if version == 0 && checksum != 1 {
+		return false
+	}
+	if version > 0 && checksum != 0x2bc830a3 {
+		return false
+	}
+
+	// Convert the program from 5-bit groups and check its length bounds.
+	bits := (len(data) - 1 - 6) * 5
+	programLen := bits / 8
+	if programLen < 2 || programLen > 40 {
+		return false
+	}
+	if version == 0 && programLen != 20 && programLen != 32 {
+		return false
+	}
+	return true
+}
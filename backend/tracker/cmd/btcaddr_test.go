@@ -0,0 +1,36 @@
+package main
+
+import "testing"
+
+func TestValidBTCAddress(t *testing.T) {
+	valid := []string{
+		"1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa",                             // P2PKH (genesis)
+		"3J98t1WpEZ73CNmQviecrnyiWrnqRhWNLy",                             // P2SH
+		"mipcBbFg9gMiCh81Kj8tqqdgoZub1ZJRfn",                             // testnet P2PKH
+		"bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4",                     // segwit v0 (BIP-173)
+		"BC1QW508D6QEJXTDG4Y5R3ZARVARY0C5XW7KV8F3T4",                     // uppercase form is legal
+		"tb1qw508d6qejxtdg4y5r3zarvary0c5xw7kxpjzsx",                     // testnet segwit v0
+		"bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vqzk5jj0", // taproot (bech32m, BIP-350)
+	}
+	for _, address := range valid {
+		if !validBTCAddress(address) {
+			t.Errorf("validBTCAddress(%q) = false, want true", address)
+		}
+	}
+
+	invalid := []string{
+		"",
+		"not-an-address",
+		"1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNb", // corrupted base58 checksum
+		"1A1zP1eP5QGefi2DMPTfTL5SLmv7D0vfNa", // '0' is not in the base58 alphabet
+		"bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t5",                     // corrupted bech32 checksum
+		"bc1Qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4",                     // mixed case
+		"bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vqh8nc4c", // taproot with bech32 (not bech32m) checksum
+		"0x52908400098527886E0F7030069857D2E4169EE7",                     // an ETH address is not a BTC target
+	}
+	for _, address := range invalid {
+		if validBTCAddress(address) {
+			t.Errorf("validBTCAddress(%q) = true, want false", address)
+		}
+	}
+}
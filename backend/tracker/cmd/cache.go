@@ -0,0 +1,134 @@
+package main
+
+import (
+	"container/list"
+	"database/sql"
+	"math/big"
+	"net/http"
+	"sync"
+)
+
+// An optional LRU in front of single-address balance lookups. Dashboards
+// hammer the same handful of hot addresses; serving them from memory keeps
+// that load off the database. The cache is kept coherent by the write
+// path: every balance mutation updates or evicts the entry.
+type holderCacheState struct {
+	mu    sync.Mutex
+	max   int
+	ll    *list.List
+	items map[string]*list.Element
+}
+
+// Balances are cached as their decimal strings, the form the API serves and
+// the only one that holds a full uint256.
+type holderCacheEntry struct {
+	address string
+	balance string
+}
+
+var holderCache holderCacheState
+
+func initHolderCache() {
+	size := int(envUint("HOLDER_CACHE_SIZE", 0))
+	if size <= 0 {
+		return
+	}
+	holderCache.max = size
+	holderCache.ll = list.New()
+	holderCache.items = make(map[string]*list.Element, size)
+}
+
+func (c *holderCacheState) get(address string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.max == 0 {
+		return "", false
+	}
+	el, ok := c.items[address]
+	if !ok {
+		return "", false
+	}
+	c.ll.MoveToFront(el)
+	return el.Value.(*holderCacheEntry).balance, true
+}
+
+func (c *holderCacheState) put(address, balance string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.max == 0 {
+		return
+	}
+	if el, ok := c.items[address]; ok {
+		el.Value.(*holderCacheEntry).balance = balance
+		c.ll.MoveToFront(el)
+		return
+	}
+	c.items[address] = c.ll.PushFront(&holderCacheEntry{address, balance})
+	if c.ll.Len() > c.max {
+		oldest := c.ll.Back()
+		c.ll.Remove(oldest)
+		delete(c.items, oldest.Value.(*holderCacheEntry).address)
+	}
+}
+
+// refresh is the write-path hook: an address already cached gets the new
+// balance immediately so readers never see a stale value.
+func (c *holderCacheState) refresh(address, balance string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.max == 0 {
+		return
+	}
+	if el, ok := c.items[address]; ok {
+		el.Value.(*holderCacheEntry).balance = balance
+	}
+}
+
+// getHolderBalance returns an address's balance as its decimal string, from
+// the cache when possible. Unknown addresses read as zero. Numeric-schema
+// rows come back through database/sql's int64-to-string conversion, so both
+// schemas serve the same shape.
+func getHolderBalance(address string) (string, error) {
+	if balance, ok := holderCache.get(address); ok {
+		return balance, nil
+	}
+	var balance string
+	err := readDB.QueryRow(q("SELECT balance FROM wbtc_holders WHERE address = ?"), address).Scan(&balance)
+	if err == sql.ErrNoRows {
+		// Cache the zero too: repeated probes of unseen addresses are as
+		// common as hot holders, and the write path refreshes the entry the
+		// moment such an address first receives funds.
+		holderCache.put(address, "0")
+		return "0", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	holderCache.put(address, balance)
+	return balance, nil
+}
+
+// handleHolderBalance serves GET /holders/{address}.
+func handleHolderBalance(w http.ResponseWriter, r *http.Request, address string) {
+	balance, err := getHolderBalance(address)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	response := map[string]interface{}{
+		"address": address,
+		"balance": balance,
+	}
+	// balance_formatted divides by the token's actual decimals() so no
+	// consumer hard-codes WBTC's 8.
+	if parsed, ok := new(big.Int).SetString(balance, 10); ok {
+		response["balance_formatted"] = formatUnits(parsed, tokenDecimals())
+	}
+	if name := ensNameFor(address); name != "" {
+		response["ens_name"] = name
+	}
+	if label := labelFor(address); label != "" {
+		response["label"] = label
+	}
+	writeJSON(w, response)
+}
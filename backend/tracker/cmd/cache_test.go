@@ -0,0 +1,57 @@
+package main
+
+import (
+	"container/list"
+	"math/big"
+	"testing"
+)
+
+func setupTestHolderCache(t *testing.T, size int) {
+	t.Helper()
+	holderCache = holderCacheState{
+		max:   size,
+		ll:    list.New(),
+		items: make(map[string]*list.Element, size),
+	}
+	t.Cleanup(func() { holderCache = holderCacheState{} })
+}
+
+func TestHolderCacheFreshAfterMutation(t *testing.T) {
+	setupTestDB(t)
+	setupTestHolderCache(t, 16)
+	readDB = db
+	const addr = "0x00000000000000000000000000000000000000dd"
+
+	updateBalance(addr, big.NewInt(50), 1)
+	if got, err := getHolderBalance(addr); err != nil || got != "50" {
+		t.Fatalf("getHolderBalance = %s, %v; want 50", got, err)
+	}
+	// The entry is now cached; a further mutation must be visible.
+	updateBalance(addr, big.NewInt(25), 2)
+	if got, err := getHolderBalance(addr); err != nil || got != "75" {
+		t.Errorf("getHolderBalance after mutation = %s, %v; want 75", got, err)
+	}
+}
+
+func TestHolderCacheEvictsOldest(t *testing.T) {
+	setupTestDB(t)
+	setupTestHolderCache(t, 2)
+	readDB = db
+
+	updateBalance("0x00000000000000000000000000000000000000e1", big.NewInt(1), 1)
+	for _, addr := range []string{
+		"0x00000000000000000000000000000000000000e1",
+		"0x00000000000000000000000000000000000000e2",
+		"0x00000000000000000000000000000000000000e3",
+	} {
+		if _, err := getHolderBalance(addr); err != nil {
+			t.Fatalf("getHolderBalance(%s): %v", addr, err)
+		}
+	}
+	if holderCache.ll.Len() != 2 {
+		t.Errorf("cache holds %d entries, want 2", holderCache.ll.Len())
+	}
+	if _, ok := holderCache.get("0x00000000000000000000000000000000000000e1"); ok {
+		t.Errorf("oldest entry was not evicted")
+	}
+}
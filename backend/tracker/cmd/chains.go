@@ -0,0 +1,302 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/big"
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Multi-chain tracking. CHAINS_CONFIG holds a JSON list of additional
+// chains to index in the same process:
+//
+//	[{"name":"sepolia","rpc_url":"wss://…","contract":"0x…","symbol":"tWBTC","starting_block":12345,"confirmations":2}]
+//
+// Each chain runs a fully independent loop — its own RPC client, its own
+// cursor (a tracker_state key scoped by chain name), its own holders table
+// — so a mainnet deployment, a Sepolia rehearsal and an L2 can share one
+// process without sharing any failure mode except the database. A
+// supervisor goroutine restarts a chain loop that panics and exposes
+// per-chain position and errors on /chains. Like extra tokens, secondary
+// chains get balances and position metrics, not the primary pipeline's
+// full feature set.
+type chainConfig struct {
+	Name          string `json:"name"`
+	RPCURL        string `json:"rpc_url"`
+	Contract      string `json:"contract"`
+	Symbol        string `json:"symbol"`
+	StartingBlock uint64 `json:"starting_block"`
+	Confirmations uint64 `json:"confirmations"`
+}
+
+type chainState struct {
+	sync.Mutex
+	lastProcessed uint64
+	latest        uint64
+	lastError     string
+	restarts      int
+	updatedAt     time.Time
+}
+
+var (
+	chainConfigs []chainConfig
+	chainStates  = map[string]*chainState{}
+)
+
+// chainNamePattern keeps names safe to splice into table identifiers.
+var chainNamePattern = regexp.MustCompile(`^[a-z0-9_]+$`)
+
+func initChains() {
+	raw := os.Getenv("CHAINS_CONFIG")
+	if raw == "" {
+		return
+	}
+	if err := json.Unmarshal([]byte(raw), &chainConfigs); err != nil {
+		log.Fatalf("Invalid CHAINS_CONFIG: %v", err)
+	}
+	for _, cfg := range chainConfigs {
+		if !chainNamePattern.MatchString(cfg.Name) {
+			log.Fatalf("CHAINS_CONFIG chain name %q must match %s", cfg.Name, chainNamePattern)
+		}
+		if cfg.RPCURL == "" {
+			log.Fatalf("CHAINS_CONFIG chain %s has no rpc_url", cfg.Name)
+		}
+		if !common.IsHexAddress(cfg.Contract) {
+			log.Fatalf("CHAINS_CONFIG chain %s has invalid contract %q", cfg.Name, cfg.Contract)
+		}
+		table := chainHoldersTable(cfg.Name)
+		_, err := db.Exec(`CREATE TABLE IF NOT EXISTS ` + table + ` (
+			address TEXT PRIMARY KEY,
+			balance ` + amountColumnDef() + `,
+			last_active_block INTEGER NOT NULL DEFAULT 0
+		)`)
+		if err != nil {
+			log.Fatalf("Failed to create %s table: %v", table, err)
+		}
+		chainStates[cfg.Name] = &chainState{}
+	}
+}
+
+// startChains launches the supervisor once the rest of startup is done.
+func startChains() {
+	for _, cfg := range chainConfigs {
+		cfg := cfg
+		log.Printf("Tracking chain %s: %s on %s from block %d", cfg.Name, cfg.Contract, cfg.RPCURL, cfg.StartingBlock)
+		go superviseChain(cfg)
+	}
+}
+
+// superviseChain keeps one chain's loop alive: a panic is logged and
+// counted, and the loop relaunches after a pause instead of taking the
+// process (and every other chain) down with it.
+func superviseChain(cfg chainConfig) {
+	for !stopRequested.Load() {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					state := chainStates[cfg.Name]
+					state.Lock()
+					state.restarts++
+					state.lastError = fmt.Sprintf("panic: %v", r)
+					state.Unlock()
+					log.Printf("Chain %s loop panicked: %v; restarting", cfg.Name, r)
+				}
+			}()
+			trackChain(cfg)
+		}()
+		if stopRequested.Load() {
+			return
+		}
+		sleepOrShutdown(30 * time.Second)
+	}
+}
+
+func chainHoldersTable(name string) string {
+	return "chain_holders_" + name
+}
+
+func chainCursorKey(name string) string {
+	return "chain_cursor:" + name
+}
+
+func loadChainCursor(name string, startingBlock uint64) uint64 {
+	var value string
+	err := db.QueryRow(q("SELECT value FROM tracker_state WHERE key = ?"), chainCursorKey(name)).Scan(&value)
+	if err == sql.ErrNoRows {
+		return startingBlock
+	}
+	if err != nil {
+		log.Fatalf("Failed to load cursor for chain %s: %v", name, err)
+	}
+	block, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		log.Fatalf("Corrupt cursor %q for chain %s: %v", value, name, err)
+	}
+	return block
+}
+
+func saveChainCursor(name string, block uint64) {
+	_, err := db.Exec(q(`INSERT INTO tracker_state (key, value) VALUES (?, ?)
+		ON CONFLICT(key) DO UPDATE SET value = excluded.value`),
+		chainCursorKey(name), strconv.FormatUint(block, 10))
+	if err != nil {
+		log.Printf("Failed to save cursor for chain %s: %v", name, err)
+	}
+}
+
+func noteChainPosition(name string, lastProcessed, latest uint64) {
+	state := chainStates[name]
+	state.Lock()
+	state.lastProcessed = lastProcessed
+	state.latest = latest
+	state.updatedAt = time.Now()
+	state.Unlock()
+}
+
+func noteChainError(name string, err error) {
+	state := chainStates[name]
+	state.Lock()
+	state.lastError = err.Error()
+	state.updatedAt = time.Now()
+	state.Unlock()
+}
+
+// trackChain is the per-chain indexing loop: the windowed Transfer scan
+// against the chain's own endpoint, cursor and table. It returns only on
+// shutdown or a panic (which the supervisor absorbs).
+func trackChain(cfg chainConfig) {
+	client, err := dialEthEndpoint(cfg.RPCURL)
+	if err != nil {
+		noteChainError(cfg.Name, err)
+		log.Printf("Chain %s: failed to connect to %s: %v", cfg.Name, cfg.RPCURL, err)
+		return
+	}
+	defer client.Close()
+
+	contract := common.HexToAddress(cfg.Contract)
+	table := chainHoldersTable(cfg.Name)
+	transferTopic := contractABI.Events["Transfer"].ID
+	window := newAdaptiveWindow()
+	lastProcessed := loadChainCursor(cfg.Name, cfg.StartingBlock)
+
+	var latest uint64
+	for {
+		if stopRequested.Load() {
+			return
+		}
+		head, err := client.BlockNumber(rootCtx)
+		if err != nil {
+			noteChainError(cfg.Name, err)
+			logDeduped("Chain "+cfg.Name+": failed to get latest block", err)
+			sleepOrShutdown(15 * time.Second)
+			continue
+		}
+		clearLogDedupe("Chain " + cfg.Name + ": failed to get latest block")
+		latest = head
+		target := computeTargetBlock(latest, cfg.Confirmations)
+		if latest == 0 || lastProcessed >= target {
+			noteChainPosition(cfg.Name, lastProcessed, latest)
+			updatePositionMetrics(contract.Hex(), cfg.Symbol+"@"+cfg.Name, lastProcessed, latest)
+			sleepOrShutdown(15 * time.Second)
+			continue
+		}
+
+		fromBlock := lastProcessed + 1
+		toBlock := fromBlock + window.size() - 1
+		if toBlock > target {
+			toBlock = target
+		}
+		logs, err := client.FilterLogs(rootCtx, ethereum.FilterQuery{
+			FromBlock: new(big.Int).SetUint64(fromBlock),
+			ToBlock:   new(big.Int).SetUint64(toBlock),
+			Addresses: []common.Address{contract},
+			Topics:    [][]common.Hash{{transferTopic}},
+		})
+		if err != nil {
+			if window.shrinkOnError(err) {
+				continue
+			}
+			noteChainError(cfg.Name, err)
+			logDeduped("Chain "+cfg.Name+": failed to filter logs", err)
+			sleepOrShutdown(15 * time.Second)
+			continue
+		}
+		clearLogDedupe("Chain " + cfg.Name + ": failed to filter logs")
+		window.observe(len(logs))
+
+		for _, vLog := range logs {
+			if len(vLog.Topics) < 3 {
+				continue
+			}
+			var transferEvent struct {
+				Value *big.Int
+			}
+			if err := decodeEvent("Transfer", &transferEvent, vLog); err != nil {
+				log.Printf("Chain %s: skipping undecodable Transfer log (tx %s): %v", cfg.Name, vLog.TxHash.Hex(), err)
+				continue
+			}
+			from := common.HexToAddress(vLog.Topics[1].Hex()).Hex()
+			to := common.HexToAddress(vLog.Topics[2].Hex()).Hex()
+			amount := transferEvent.Value
+			if from == to {
+				continue
+			}
+			if from != zeroAddress {
+				applyExtraTokenDelta(table, from, new(big.Int).Neg(amount), vLog.BlockNumber)
+			}
+			if to != zeroAddress {
+				applyExtraTokenDelta(table, to, amount, vLog.BlockNumber)
+			}
+		}
+		lastProcessed = toBlock
+		saveChainCursor(cfg.Name, lastProcessed)
+		noteChainPosition(cfg.Name, lastProcessed, latest)
+		updatePositionMetrics(contract.Hex(), cfg.Symbol+"@"+cfg.Name, lastProcessed, latest)
+	}
+}
+
+// chainStalled is how long a chain may go without a position update before
+// /chains reports it stalled.
+const chainStalled = 5 * time.Minute
+
+// handleChains serves GET /chains: per-chain position, lag, restart count
+// and last error for every configured secondary chain.
+func handleChains(w http.ResponseWriter, r *http.Request) {
+	chains := []map[string]interface{}{}
+	for _, cfg := range chainConfigs {
+		state := chainStates[cfg.Name]
+		state.Lock()
+		lag := uint64(0)
+		if state.latest > state.lastProcessed {
+			lag = state.latest - state.lastProcessed
+		}
+		entry := map[string]interface{}{
+			"name":           cfg.Name,
+			"contract":       common.HexToAddress(cfg.Contract).Hex(),
+			"symbol":         cfg.Symbol,
+			"last_processed": state.lastProcessed,
+			"latest":         state.latest,
+			"lag":            lag,
+			"restarts":       state.restarts,
+			"stalled":        state.updatedAt.IsZero() || time.Since(state.updatedAt) > chainStalled,
+		}
+		if state.lastError != "" {
+			entry["last_error"] = state.lastError
+		}
+		state.Unlock()
+		chains = append(chains, entry)
+	}
+	writeJSON(w, map[string]interface{}{
+		"chains": chains,
+	})
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// An in-memory feed of recent balance changes, each stamped with a
+// monotonic sequence number, so lightweight clients can long-poll
+// GET /changes?since=<cursor> instead of holding a websocket. Balances ride
+// as decimal strings so a uint256 value survives JSON intact.
+type balanceChange struct {
+	Seq     uint64 `json:"seq"`
+	Address string `json:"address"`
+	Old     string `json:"old"`
+	New     string `json:"new"`
+	Block   uint64 `json:"block"`
+}
+
+var changeFeed struct {
+	sync.Mutex
+	buf     []balanceChange
+	max     int
+	nextSeq uint64
+	notify  chan struct{}
+}
+
+var changesTimeout time.Duration
+
+func initChangeFeed() {
+	changeFeed.max = int(envUint("CHANGES_BUFFER", 4096))
+	changeFeed.notify = make(chan struct{})
+	changeFeed.nextSeq = 1
+	changesTimeout = envDuration("CHANGES_TIMEOUT", 30*time.Second)
+}
+
+func publishChange(address string, oldBalance, newBalance *big.Int, block uint64) {
+	changeFeed.Lock()
+	// Lazy defaults so direct updateBalance callers (tests, tools) work
+	// without the full server bootstrap.
+	if changeFeed.max == 0 {
+		changeFeed.max = 4096
+	}
+	if changeFeed.nextSeq == 0 {
+		changeFeed.nextSeq = 1
+	}
+	change := balanceChange{
+		Seq:     changeFeed.nextSeq,
+		Address: address,
+		Old:     oldBalance.String(),
+		New:     newBalance.String(),
+		Block:   block,
+	}
+	changeFeed.nextSeq++
+	changeFeed.buf = append(changeFeed.buf, change)
+	if len(changeFeed.buf) > changeFeed.max {
+		changeFeed.buf = changeFeed.buf[len(changeFeed.buf)-changeFeed.max:]
+	}
+	if changeFeed.notify != nil {
+		close(changeFeed.notify)
+	}
+	changeFeed.notify = make(chan struct{})
+	changeFeed.Unlock()
+}
+
+// collectChanges returns the changes after the cursor, the notification
+// channel to wait on if there are none, and whether the cursor has fallen
+// off the buffer (the client must reset and re-sync its state).
+func collectChanges(since uint64) ([]balanceChange, chan struct{}, bool) {
+	changeFeed.Lock()
+	defer changeFeed.Unlock()
+	oldest := changeFeed.nextSeq - uint64(len(changeFeed.buf))
+	if since+1 < oldest {
+		return nil, nil, true
+	}
+	var out []balanceChange
+	for _, c := range changeFeed.buf {
+		if c.Seq > since {
+			out = append(out, c)
+		}
+	}
+	return out, changeFeed.notify, false
+}
+
+// handleChanges serves the long-poll: it blocks up to CHANGES_TIMEOUT for
+// new changes past the cursor, then returns whatever is available together
+// with a new cursor. A cursor older than the buffer gets a reset signal.
+func handleChanges(w http.ResponseWriter, r *http.Request) {
+	since, _ := strconv.ParseUint(r.URL.Query().Get("since"), 10, 64)
+	deadline := time.NewTimer(changesTimeout)
+	defer deadline.Stop()
+
+	for {
+		changes, notify, reset := collectChanges(since)
+		if reset {
+			changeFeed.Lock()
+			cursor := changeFeed.nextSeq - 1
+			changeFeed.Unlock()
+			writeJSON(w, map[string]interface{}{"reset": true, "cursor": cursor})
+			return
+		}
+		if len(changes) > 0 {
+			writeJSON(w, map[string]interface{}{
+				"cursor":  changes[len(changes)-1].Seq,
+				"changes": changes,
+			})
+			return
+		}
+		select {
+		case <-notify:
+		case <-deadline.C:
+			writeJSON(w, map[string]interface{}{"cursor": since, "changes": []balanceChange{}})
+			return
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	encoded, err := json.Marshal(injectAPIContext(v))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Write(restyleJSON(append(encoded, '\n')))
+}
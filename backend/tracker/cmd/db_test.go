@@ -0,0 +1,159 @@
+package main
+
+import (
+	"database/sql"
+	"math/big"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func setupTestDB(t *testing.T) {
+	t.Helper()
+	dbType = "sqlite"
+	var err error
+	db, err = sql.Open("sqlite3", filepath.Join(t.TempDir(), "test.db")+"?_busy_timeout=5000")
+	if err != nil {
+		t.Fatalf("open test db: %v", err)
+	}
+	if err := runMigrations(); err != nil {
+		t.Fatalf("migrate schema: %v", err)
+	}
+	initTimestamps()
+	readDB = db
+	t.Cleanup(func() { db.Close() })
+}
+
+func getBalance(t *testing.T, address string) int64 {
+	t.Helper()
+	var balance int64
+	err := db.QueryRow(q("SELECT balance FROM wbtc_holders WHERE address = ?"), address).Scan(&balance)
+	if err != nil {
+		t.Fatalf("read balance: %v", err)
+	}
+	return balance
+}
+
+// getBalanceText reads the stored balance as the exact decimal string, for
+// values beyond int64.
+func getBalanceText(t *testing.T, address string) string {
+	t.Helper()
+	var balance string
+	err := db.QueryRow(q("SELECT balance FROM wbtc_holders WHERE address = ?"), address).Scan(&balance)
+	if err != nil {
+		t.Fatalf("read balance: %v", err)
+	}
+	return balance
+}
+
+func TestResolveDatabaseURL(t *testing.T) {
+	tests := []struct {
+		dbType, url string
+		want        string
+		wantErr     bool
+	}{
+		{"sqlite", "", "./tracker.db", false},
+		{"sqlite", "/data/wbtc.db", "/data/wbtc.db", false},
+		{"postgres", "", "", true},
+		{"postgres", "postgres://localhost/wbtc", "postgres://localhost/wbtc", false},
+	}
+	for _, tt := range tests {
+		got, err := resolveDatabaseURL(tt.dbType, tt.url)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("resolveDatabaseURL(%q, %q) error = %v, wantErr %v", tt.dbType, tt.url, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("resolveDatabaseURL(%q, %q) = %q, want %q", tt.dbType, tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestUpdateBalanceConcurrentDeltas(t *testing.T) {
+	setupTestDB(t)
+	const (
+		addr       = "0x00000000000000000000000000000000000000aa"
+		workers    = 8
+		perWorker  = 50
+		deltaValue = 3
+	)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < perWorker; j++ {
+				updateBalance(addr, big.NewInt(deltaValue), 1)
+			}
+		}()
+	}
+	wg.Wait()
+	want := int64(workers * perWorker * deltaValue)
+	if got := getBalance(t, addr); got != want {
+		t.Errorf("balance after concurrent deltas = %d, want %d", got, want)
+	}
+}
+
+func TestUpdateBalanceNegativeDelta(t *testing.T) {
+	setupTestDB(t)
+	const addr = "0x00000000000000000000000000000000000000bb"
+	updateBalance(addr, big.NewInt(100), 1)
+	updateBalance(addr, big.NewInt(-40), 2)
+	if got := getBalance(t, addr); got != 60 {
+		t.Errorf("balance = %d, want 60", got)
+	}
+}
+
+func TestUpdateBalanceBeyondInt64(t *testing.T) {
+	setupTestDB(t)
+	const addr = "0x00000000000000000000000000000000000000be"
+	// A realistic uint256 amount, far beyond int64.
+	huge := new(big.Int).Lsh(big.NewInt(1), 70)
+	updateBalance(addr, huge, 1)
+	updateBalance(addr, big.NewInt(1), 2)
+	want := new(big.Int).Add(huge, big.NewInt(1)).String()
+	if got := getBalanceText(t, addr); got != want {
+		t.Errorf("balance = %s, want %s", got, want)
+	}
+	// And back down, exactly.
+	updateBalance(addr, new(big.Int).Neg(huge), 3)
+	if got := getBalanceText(t, addr); got != "1" {
+		t.Errorf("balance after debit = %s, want 1", got)
+	}
+}
+
+func TestHolderMinBalanceExcludesDust(t *testing.T) {
+	setupTestDB(t)
+	const (
+		dust  = "0x00000000000000000000000000000000000000d1"
+		whale = "0x00000000000000000000000000000000000000d2"
+	)
+	updateBalance(dust, big.NewInt(5), 1)
+	updateBalance(whale, big.NewInt(5000), 1)
+
+	saved := minHolderBalance
+	minHolderBalance = 100
+	defer func() { minHolderBalance = saved }()
+
+	count, err := visibleHolderCount()
+	if err != nil {
+		t.Fatalf("visibleHolderCount: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("holder count with min 100 = %d, want 1 (dust excluded)", count)
+	}
+	// The dust balance is still stored and queryable directly.
+	if got := getBalance(t, dust); got != 5 {
+		t.Errorf("dust balance = %d, want 5", got)
+	}
+}
+
+func TestSetBalanceOverwrites(t *testing.T) {
+	setupTestDB(t)
+	const addr = "0x00000000000000000000000000000000000000cc"
+	updateBalance(addr, big.NewInt(100), 1)
+	setBalance(addr, big.NewInt(7), 2)
+	if got := getBalance(t, addr); got != 7 {
+		t.Errorf("balance after setBalance = %d, want 7", got)
+	}
+}
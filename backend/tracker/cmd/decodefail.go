@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// Logs that fail to decode are not just counted — their raw form (topics,
+// data, position) is kept in decode_failures so that after an ABI fix the
+// retry-decodes command can re-attempt exactly those logs instead of
+// resyncing the whole range they came from. Rows are deleted once a retry
+// succeeds, so a second run cannot apply the same log twice.
+
+// recordDecodeFailure counts a failed decode and preserves the raw log for a
+// later retry-decodes pass.
+func recordDecodeFailure(event string, vLog types.Log) {
+	decodeFailures.WithLabelValues(event).Inc()
+	topics := make([]string, len(vLog.Topics))
+	for i, topic := range vLog.Topics {
+		topics[i] = topic.Hex()
+	}
+	_, err := db.Exec(q(`INSERT INTO decode_failures (tx_hash, log_index, block, event, topics, data)
+		VALUES (?, ?, ?, ?, ?, ?) ON CONFLICT(tx_hash, log_index) DO NOTHING`),
+		vLog.TxHash.Hex(), vLog.Index, vLog.BlockNumber, event,
+		strings.Join(topics, ","), common.Bytes2Hex(vLog.Data))
+	if err != nil {
+		log.Printf("Failed to record decode failure (tx %s, index %d): %v", vLog.TxHash.Hex(), vLog.Index, err)
+	}
+}
+
+// runRetryDecodes implements the retry-decodes subcommand: re-attempt every
+// stored failure against the current ABI, dispatch the ones that now decode,
+// and clear them. Logs still failing stay in the table for the next fix.
+func runRetryDecodes(args []string) {
+	openDatabase()
+	initHistory()
+	initStatCache()
+	initDenylist()
+	initAddressFilter()
+	initLedger()
+	initWriteBuffer()
+	initSinks()
+	initHolderCache()
+	initSafeMode()
+	initSupplyCheck()
+
+	rows, err := db.Query(`SELECT tx_hash, log_index, block, event, topics, data FROM decode_failures ORDER BY block, log_index`)
+	if err != nil {
+		log.Fatalf("Failed to read decode failures: %v", err)
+	}
+	type failure struct {
+		vLog  types.Log
+		event string
+	}
+	var failures []failure
+	for rows.Next() {
+		var txHash, event, topics, data string
+		var index uint
+		var block uint64
+		if err := rows.Scan(&txHash, &index, &block, &event, &topics, &data); err != nil {
+			log.Fatalf("Failed to scan decode failure: %v", err)
+		}
+		vLog := types.Log{
+			TxHash:      common.HexToHash(txHash),
+			Index:       index,
+			BlockNumber: block,
+			Data:        common.Hex2Bytes(data),
+		}
+		for _, topic := range strings.Split(topics, ",") {
+			if topic != "" {
+				vLog.Topics = append(vLog.Topics, common.HexToHash(topic))
+			}
+		}
+		failures = append(failures, failure{vLog: vLog, event: event})
+	}
+	if err := rows.Err(); err != nil {
+		log.Fatalf("Failed to read decode failures: %v", err)
+	}
+	rows.Close()
+
+	retried, repaired := 0, 0
+	for _, f := range failures {
+		retried++
+		if _, _, err := decodeWithABI(contractABI, f.vLog); err != nil {
+			continue
+		}
+		// Clearing the row before dispatch keeps a rerun from applying the
+		// same log twice even if the handler re-records a failure.
+		if _, err := db.Exec(q(`DELETE FROM decode_failures WHERE tx_hash = ? AND log_index = ?`),
+			f.vLog.TxHash.Hex(), f.vLog.Index); err != nil {
+			log.Printf("Failed to clear decode failure (tx %s, index %d): %v", f.vLog.TxHash.Hex(), f.vLog.Index, err)
+			continue
+		}
+		// The original dispatch marked the pair processed even though the
+		// handler applied nothing; forget it so this repair isn't skipped.
+		clearProcessedEvent(f.vLog.TxHash.Hex(), f.vLog.Index)
+		dispatchLog(f.vLog)
+		repaired++
+	}
+	fmt.Printf("retry-decodes: retried %d stored failures, repaired %d, %d still failing\n", retried, repaired, retried-repaired)
+}
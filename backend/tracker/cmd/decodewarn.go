@@ -0,0 +1,68 @@
+package main
+
+import (
+	"log"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Non-standard tokens can emit events whose data layout differs from the ABI
+// compiled into the tracker. UnpackIntoInterface then either errors or, worse,
+// succeeds while leaving struct fields unset. nilDecodedFields catches the
+// silent case, and warnDecodeMismatch keeps the logs readable: each event
+// signature is warned about at most once per decodeWarnInterval instead of
+// once per log, while every occurrence still counts in the metric.
+var (
+	decodeMismatches = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "decode_mismatches_total",
+		Help: "Number of logs whose decoded fields did not match the expected layout, by event name.",
+	}, []string{"event"})
+
+	decodeWarnInterval = time.Hour
+
+	decodeWarnMu   sync.Mutex
+	decodeWarnLast = map[string]time.Time{}
+)
+
+// nilDecodedFields returns the names of struct fields that UnpackIntoInterface
+// left unset. A successful unpack fills every field, so a nil pointer or slice
+// here means the event's data carried fewer values than the ABI declares.
+func nilDecodedFields(out interface{}) []string {
+	v := reflect.ValueOf(out)
+	if v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+	var missing []string
+	for i := 0; i < v.NumField(); i++ {
+		switch f := v.Field(i); f.Kind() {
+		case reflect.Ptr, reflect.Slice, reflect.Map, reflect.Interface:
+			if f.IsNil() {
+				missing = append(missing, v.Type().Field(i).Name)
+			}
+		}
+	}
+	return missing
+}
+
+// warnDecodeMismatch records a layout mismatch for the named event and logs it
+// at most once per decodeWarnInterval.
+func warnDecodeMismatch(event, detail string) {
+	decodeMismatches.WithLabelValues(event).Inc()
+	now := time.Now()
+	decodeWarnMu.Lock()
+	last, seen := decodeWarnLast[event]
+	if seen && now.Sub(last) < decodeWarnInterval {
+		decodeWarnMu.Unlock()
+		return
+	}
+	decodeWarnLast[event] = now
+	decodeWarnMu.Unlock()
+	log.Printf("WARNING: %s logs do not match the compiled ABI: %s (repeats suppressed for %s)", event, detail, decodeWarnInterval)
+}
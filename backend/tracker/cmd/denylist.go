@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Denylisted addresses are excluded from balance tracking entirely: they
+// are neither credited nor debited. The counterparty's side of a transfer
+// is still applied, so the sum of tracked balances will not include value
+// parked at denylisted addresses and supply-style totals must account for
+// that.
+var (
+	denylistMu sync.RWMutex
+	denylist   = map[string]bool{}
+)
+
+func initDenylist() {
+	setDenylist(strings.Split(os.Getenv("DENYLIST"), ","))
+}
+
+func setDenylist(addresses []string) {
+	next := map[string]bool{}
+	for _, a := range addresses {
+		a = strings.TrimSpace(a)
+		if a == "" {
+			continue
+		}
+		next[common.HexToAddress(a).Hex()] = true
+	}
+	denylistMu.Lock()
+	denylist = next
+	denylistMu.Unlock()
+	if len(next) > 0 {
+		log.Printf("Denylist active for %d addresses", len(next))
+	}
+}
+
+func isDenylisted(address string) bool {
+	denylistMu.RLock()
+	defer denylistMu.RUnlock()
+	return denylist[address]
+}
+
+// requireAdmin gates the admin endpoints on the ADMIN_TOKEN env var. With
+// no token configured the endpoints are disabled outright rather than open.
+func requireAdmin(w http.ResponseWriter, r *http.Request) bool {
+	token := os.Getenv("ADMIN_TOKEN")
+	if token == "" {
+		http.Error(w, "admin endpoints disabled: ADMIN_TOKEN not configured", http.StatusForbidden)
+		return false
+	}
+	if r.Header.Get("X-Admin-Token") != token {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return false
+	}
+	return true
+}
+
+// handleAdminDenylist serves GET /admin/denylist (current list) and POST
+// /admin/denylist (replace the list with a JSON array) so the denylist can
+// be changed without a restart.
+func handleAdminDenylist(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		denylistMu.RLock()
+		current := make([]string, 0, len(denylist))
+		for a := range denylist {
+			current = append(current, a)
+		}
+		denylistMu.RUnlock()
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(current)
+	case http.MethodPost:
+		var addresses []string
+		if err := json.NewDecoder(r.Body).Decode(&addresses); err != nil {
+			http.Error(w, "body must be a JSON array of addresses", http.StatusBadRequest)
+			return
+		}
+		setDenylist(addresses)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"database/sql"
+	"math/big"
+	"testing"
+)
+
+func balanceOrMissing(t *testing.T, address string) (int64, bool) {
+	t.Helper()
+	var balance int64
+	err := db.QueryRow(q("SELECT balance FROM wbtc_holders WHERE address = ?"), address).Scan(&balance)
+	if err == sql.ErrNoRows {
+		return 0, false
+	}
+	if err != nil {
+		t.Fatalf("read balance: %v", err)
+	}
+	return balance, true
+}
+
+func TestDenylistSkipsOneSideOfTransfer(t *testing.T) {
+	setupTestDB(t)
+	const (
+		from = "0x1111111111111111111111111111111111111111"
+		to   = "0x2222222222222222222222222222222222222222"
+	)
+	setDenylist([]string{from})
+	defer setDenylist(nil)
+
+	// Simulate the two sides of a transfer the way handleTransferEvent does.
+	updateBalance(from, big.NewInt(-25), 1)
+	updateBalance(to, big.NewInt(25), 1)
+
+	if _, ok := balanceOrMissing(t, from); ok {
+		t.Errorf("denylisted sender was written to wbtc_holders")
+	}
+	if got, ok := balanceOrMissing(t, to); !ok || got != 25 {
+		t.Errorf("counterparty balance = %d (present=%v), want 25", got, ok)
+	}
+}
+
+func TestDenylistSkipsBothSides(t *testing.T) {
+	setupTestDB(t)
+	const (
+		from = "0x3333333333333333333333333333333333333333"
+		to   = "0x4444444444444444444444444444444444444444"
+	)
+	setDenylist([]string{from, to})
+	defer setDenylist(nil)
+
+	updateBalance(from, big.NewInt(-10), 1)
+	updateBalance(to, big.NewInt(10), 1)
+
+	if _, ok := balanceOrMissing(t, from); ok {
+		t.Errorf("denylisted sender was written to wbtc_holders")
+	}
+	if _, ok := balanceOrMissing(t, to); ok {
+		t.Errorf("denylisted recipient was written to wbtc_holders")
+	}
+}
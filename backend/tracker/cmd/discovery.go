@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// STARTING_BLOCK is no longer mandatory for a fresh database: left unset,
+// the tracker binary-searches eth_getCode for the block where the contract
+// was deployed and starts indexing there. That is ~log2(head) state
+// probes, run once; the result then persists through the normal
+// checkpoint. Historical state queries need an archive endpoint — against
+// a pruned node the probes fail, and the error says to set STARTING_BLOCK
+// explicitly instead.
+
+// firstBlockWithCode binary-searches for the lowest block at which hasCode
+// reports true, assuming code never disappears once deployed
+// (self-destructed contracts are not worth indexing anyway).
+func firstBlockWithCode(latest uint64, hasCode func(uint64) (bool, error)) (uint64, error) {
+	deployed, err := hasCode(latest)
+	if err != nil {
+		return 0, err
+	}
+	if !deployed {
+		return 0, fmt.Errorf("no code at head block %d", latest)
+	}
+	var lo uint64
+	hi := latest
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+		deployed, err := hasCode(mid)
+		if err != nil {
+			return 0, err
+		}
+		if deployed {
+			hi = mid
+		} else {
+			lo = mid + 1
+		}
+	}
+	return lo, nil
+}
+
+// discoverStartingBlock resolves the checkpoint a fresh database should
+// start from when STARTING_BLOCK is unset: one block before the contract's
+// creation, so the creation block itself is indexed.
+func discoverStartingBlock() uint64 {
+	if currentEthClient() == nil {
+		log.Fatalf("STARTING_BLOCK is required for a fresh database when no node is connected")
+	}
+	address := common.HexToAddress(os.Getenv("WBTC_ADDRESS"))
+	latest, err := currentEthClient().BlockNumber(rootCtx)
+	if err != nil {
+		log.Fatalf("Failed to auto-discover creation block for %s: cannot fetch head: %v", address.Hex(), err)
+	}
+	created, err := firstBlockWithCode(latest, func(block uint64) (bool, error) {
+		code, err := currentEthClient().CodeAt(rootCtx, address, new(big.Int).SetUint64(block))
+		if err != nil {
+			return false, fmt.Errorf("eth_getCode at block %d: %w (historical state required; set STARTING_BLOCK to skip discovery)", block, err)
+		}
+		return len(code) > 0, nil
+	})
+	if err != nil {
+		log.Fatalf("Failed to auto-discover creation block for %s: %v", address.Hex(), err)
+	}
+	log.Printf("Auto-discovered creation block %d for %s; indexing starts there", created, address.Hex())
+	if created == 0 {
+		return 0
+	}
+	return created - 1
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestFirstBlockWithCode(t *testing.T) {
+	const created = 1337
+	probes := 0
+	hasCode := func(block uint64) (bool, error) {
+		probes++
+		return block >= created, nil
+	}
+	got, err := firstBlockWithCode(1_000_000, hasCode)
+	if err != nil {
+		t.Fatalf("firstBlockWithCode: %v", err)
+	}
+	if got != created {
+		t.Errorf("creation block = %d, want %d", got, created)
+	}
+	if probes > 25 {
+		t.Errorf("binary search used %d probes for a 1M-block range", probes)
+	}
+}
+
+func TestFirstBlockWithCodeDeployedAtGenesis(t *testing.T) {
+	got, err := firstBlockWithCode(500, func(uint64) (bool, error) { return true, nil })
+	if err != nil {
+		t.Fatalf("firstBlockWithCode: %v", err)
+	}
+	if got != 0 {
+		t.Errorf("creation block = %d, want 0", got)
+	}
+}
+
+func TestFirstBlockWithCodeNeverDeployed(t *testing.T) {
+	if _, err := firstBlockWithCode(500, func(uint64) (bool, error) { return false, nil }); err == nil {
+		t.Error("expected an error for an address with no code at head")
+	}
+}
+
+func TestFirstBlockWithCodePropagatesProbeError(t *testing.T) {
+	probeErr := errors.New("missing trie node")
+	_, err := firstBlockWithCode(500, func(uint64) (bool, error) { return false, probeErr })
+	if !errors.Is(err, probeErr) {
+		t.Errorf("err = %v, want the probe error", err)
+	}
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"math/big"
+	"net/http"
+	"time"
+)
+
+// Distribution time series: how concentrated the token is and how that
+// changes over the bridge's life. A background pass recomputes the
+// non-zero holder count, the top-10 share and the Gini coefficient from
+// wbtc_holders every DISTRIBUTION_INTERVAL (default 6h, 0 disables) and
+// upserts the current UTC day's row, so each day ends with its last
+// computed value. Ratios are float64: balances up to 2^256 still fit, and
+// chart-grade stats don't need exact integer arithmetic the way balances
+// do. Served via GET /stats/distribution.
+
+// computeDistribution reads every non-zero balance in ascending order and
+// derives the stats in one pass. Gini uses the sorted-rank formula
+// G = 2*Σ(i*x_i) / (n*Σx_i) - (n+1)/n with i counted from 1.
+func computeDistribution() (holders int, top10Share, gini float64, err error) {
+	expr := balanceExpr()
+	rows, err := readDB.Query(q("SELECT balance FROM wbtc_holders WHERE " + expr + " > 0 ORDER BY " + expr + " ASC"))
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	defer rows.Close()
+
+	var balances []float64
+	balance := new(big.Int)
+	for rows.Next() {
+		var stored string
+		if err := rows.Scan(&stored); err != nil {
+			return 0, 0, 0, err
+		}
+		if _, ok := balance.SetString(stored, 10); !ok {
+			log.Printf("Corrupt balance %q in distribution pass; skipping", stored)
+			continue
+		}
+		value, _ := new(big.Float).SetInt(balance).Float64()
+		balances = append(balances, value)
+	}
+	if err := rows.Err(); err != nil {
+		return 0, 0, 0, err
+	}
+
+	n := len(balances)
+	if n == 0 {
+		return 0, 0, 0, nil
+	}
+	var total, weighted, top10 float64
+	for i, value := range balances {
+		total += value
+		weighted += float64(i+1) * value
+		if i >= n-10 {
+			top10 += value
+		}
+	}
+	if total == 0 {
+		return n, 0, 0, nil
+	}
+	return n, top10 / total, 2*weighted/(float64(n)*total) - float64(n+1)/float64(n), nil
+}
+
+func recordDistribution() {
+	holders, top10Share, gini, err := computeDistribution()
+	if err != nil {
+		log.Printf("Distribution pass failed: %v", err)
+		return
+	}
+	date := time.Now().UTC().Format("2006-01-02")
+	_, err = db.Exec(q(`INSERT INTO distribution_daily (date, holders, top10_share, gini) VALUES (?, ?, ?, ?)
+		ON CONFLICT(date) DO UPDATE SET holders = excluded.holders,
+			top10_share = excluded.top10_share, gini = excluded.gini`),
+		date, holders, top10Share, gini)
+	if err != nil {
+		log.Printf("Failed to record distribution for %s: %v", date, err)
+	}
+}
+
+// runDistributionSnapshots is the recompute loop, started once from main.
+func runDistributionSnapshots() {
+	interval := envDuration("DISTRIBUTION_INTERVAL", 6*time.Hour)
+	if interval <= 0 {
+		return
+	}
+	for {
+		sleepOrShutdown(interval)
+		if stopRequested.Load() {
+			return
+		}
+		recordDistribution()
+	}
+}
+
+// handleStatsDistribution serves GET /stats/distribution?from=&to= with the
+// same date-range convention as /stats/daily.
+func handleStatsDistribution(w http.ResponseWriter, r *http.Request) {
+	from := r.URL.Query().Get("from")
+	to := r.URL.Query().Get("to")
+	if from == "" {
+		from = "0000-00-00"
+	}
+	if to == "" {
+		to = "9999-99-99"
+	}
+	rows, err := readDB.Query(q(`SELECT date, holders, top10_share, gini
+		FROM distribution_daily WHERE date >= ? AND date <= ? ORDER BY date ASC`), from, to)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	type day struct {
+		Date       string  `json:"date"`
+		Holders    int64   `json:"holders"`
+		Top10Share float64 `json:"top10Share"`
+		Gini       float64 `json:"gini"`
+	}
+	days := []day{}
+	for rows.Next() {
+		var d day
+		if err := rows.Scan(&d.Date, &d.Holders, &d.Top10Share, &d.Gini); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		days = append(days, d)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(days)
+}
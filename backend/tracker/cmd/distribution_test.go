@@ -0,0 +1,47 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestComputeDistribution(t *testing.T) {
+	setupTestDB(t)
+	for address, balance := range map[string]string{
+		"0x0000000000000000000000000000000000000001": "100",
+		"0x0000000000000000000000000000000000000002": "200",
+		"0x0000000000000000000000000000000000000003": "300",
+		"0x0000000000000000000000000000000000000004": "400",
+		"0x0000000000000000000000000000000000000005": "0",
+	} {
+		if _, err := db.Exec(q(`INSERT INTO wbtc_holders (address, balance) VALUES (?, ?)`), address, balance); err != nil {
+			t.Fatalf("seed holder: %v", err)
+		}
+	}
+
+	holders, top10Share, gini, err := computeDistribution()
+	if err != nil {
+		t.Fatalf("computeDistribution: %v", err)
+	}
+	if holders != 4 {
+		t.Errorf("holders = %d, want 4 (zero balances excluded)", holders)
+	}
+	if top10Share != 1.0 {
+		t.Errorf("top10Share = %v, want 1.0 with fewer than 10 holders", top10Share)
+	}
+	// Sorted 100,200,300,400: G = 2*3000/(4*1000) - 5/4 = 0.25.
+	if math.Abs(gini-0.25) > 1e-9 {
+		t.Errorf("gini = %v, want 0.25", gini)
+	}
+}
+
+func TestComputeDistributionEmpty(t *testing.T) {
+	setupTestDB(t)
+	holders, top10Share, gini, err := computeDistribution()
+	if err != nil {
+		t.Fatalf("computeDistribution: %v", err)
+	}
+	if holders != 0 || top10Share != 0 || gini != 0 {
+		t.Errorf("empty table = (%d, %v, %v), want zeros", holders, top10Share, gini)
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"log"
+	"math/big"
+	"os"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Dust pruning. Airdrop spam and emptied wallets leave wbtc_holders full
+// of rows at or near zero that nobody queries but every scan pays for. At
+// DUST_PRUNE_INTERVAL (default 0 = off) a job removes holders whose
+// balance is between zero and DUST_THRESHOLD base units inclusive
+// (default 0, so only emptied wallets go). DUST_PRUNE_MODE picks what
+// removal means: "archive" (the default) moves rows to
+// wbtc_holders_archive, "delete" drops them. Because updateBalance upserts
+// deltas, an archived address that turns active again gets a fresh row
+// missing its archived dust; every prune pass therefore first folds
+// archived balances back into any live row, so the understatement lasts at
+// most one interval and is bounded by DUST_THRESHOLD. Negative balances
+// are never pruned — they are evidence of missing events, not dust.
+var (
+	dustPruned = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "tracker_dust_pruned_total",
+		Help: "Number of holder rows archived, deleted or restored by the dust pruning job.",
+	}, []string{"action"})
+)
+
+// restoreArchivedHolders folds archived dust back into addresses that have
+// become active again, keeping archive-mode pruning lossless.
+func restoreArchivedHolders() int {
+	rows, err := readDB.Query(`SELECT a.address, a.balance FROM wbtc_holders_archive a
+		JOIN wbtc_holders h ON h.address = a.address`)
+	if err != nil {
+		log.Printf("Dust pruning: failed to scan archive for reactivated holders: %v", err)
+		return 0
+	}
+	type archived struct{ address, balance string }
+	var reactivated []archived
+	for rows.Next() {
+		var item archived
+		if err := rows.Scan(&item.address, &item.balance); err != nil {
+			rows.Close()
+			log.Printf("Dust pruning: failed to scan archived holder: %v", err)
+			return 0
+		}
+		reactivated = append(reactivated, item)
+	}
+	rows.Close()
+
+	trackerStatus.Lock()
+	block := trackerStatus.lastProcessedBlock
+	trackerStatus.Unlock()
+	restored := 0
+	for _, item := range reactivated {
+		balance, ok := new(big.Int).SetString(item.balance, 10)
+		if !ok {
+			log.Printf("Dust pruning: corrupt archived balance %q for %s", item.balance, item.address)
+			continue
+		}
+		if _, err := db.Exec(q(`DELETE FROM wbtc_holders_archive WHERE address = ?`), item.address); err != nil {
+			log.Printf("Dust pruning: failed to drop archive row for %s: %v", item.address, err)
+			continue
+		}
+		updateBalance(item.address, balance, block)
+		dustPruned.WithLabelValues("restored").Inc()
+		restored++
+	}
+	return restored
+}
+
+// pruneDustHolders runs one pass: restore reactivated archives, then
+// archive or delete every holder at or below the threshold.
+func pruneDustHolders(threshold uint64, archive bool) {
+	restored := restoreArchivedHolders()
+
+	expr := balanceExpr()
+	now := time.Now().Unix()
+	var pruned int64
+	if archive {
+		result, err := db.Exec(q(`INSERT INTO wbtc_holders_archive (address, balance, archived_at)
+			SELECT address, balance, ? FROM wbtc_holders WHERE `+expr+` >= 0 AND `+expr+` <= ?
+			ON CONFLICT(address) DO UPDATE SET balance = excluded.balance, archived_at = excluded.archived_at`),
+			now, threshold)
+		if err != nil {
+			log.Printf("Dust pruning: failed to archive dust holders: %v", err)
+			return
+		}
+		pruned, _ = result.RowsAffected()
+	}
+	result, err := db.Exec(q(`DELETE FROM wbtc_holders WHERE `+expr+` >= 0 AND `+expr+` <= ?`), threshold)
+	if err != nil {
+		log.Printf("Dust pruning: failed to delete dust holders: %v", err)
+		return
+	}
+	if !archive {
+		pruned, _ = result.RowsAffected()
+	}
+	action := "deleted"
+	if archive {
+		action = "archived"
+	}
+	dustPruned.WithLabelValues(action).Add(float64(pruned))
+	if pruned > 0 || restored > 0 {
+		log.Printf("Dust pruning: %s %d holders at or below %d, restored %d reactivated", action, pruned, threshold, restored)
+	}
+}
+
+// runDustPruning is the pruning loop, started once from main.
+func runDustPruning() {
+	interval := envDuration("DUST_PRUNE_INTERVAL", 0)
+	if interval <= 0 {
+		return
+	}
+	threshold := envUint("DUST_THRESHOLD", 0)
+	archive := os.Getenv("DUST_PRUNE_MODE") != "delete"
+	mode := "archiving"
+	if !archive {
+		mode = "deleting"
+	}
+	log.Printf("Dust pruning enabled: %s holders at or below %d base units every %s", mode, threshold, interval)
+	for {
+		sleepOrShutdown(interval)
+		if stopRequested.Load() {
+			return
+		}
+		pruneDustHolders(threshold, archive)
+	}
+}
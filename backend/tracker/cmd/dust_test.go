@@ -0,0 +1,74 @@
+package main
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestPruneDustHoldersArchiveAndRestore(t *testing.T) {
+	setupTestDB(t)
+
+	dust := common.HexToAddress("0x01").Hex()
+	whale := common.HexToAddress("0x02").Hex()
+	emptied := common.HexToAddress("0x03").Hex()
+	negative := common.HexToAddress("0x04").Hex()
+	setBalance(dust, big.NewInt(5), 100)
+	setBalance(whale, big.NewInt(100000), 100)
+	setBalance(emptied, big.NewInt(0), 100)
+	setBalance(negative, big.NewInt(-3), 100)
+
+	pruneDustHolders(10, true)
+
+	var holders int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM wbtc_holders`).Scan(&holders); err != nil {
+		t.Fatalf("count holders: %v", err)
+	}
+	if holders != 2 {
+		t.Errorf("holders after prune = %d, want 2 (whale and negative)", holders)
+	}
+	var archived int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM wbtc_holders_archive`).Scan(&archived); err != nil {
+		t.Fatalf("count archive: %v", err)
+	}
+	if archived != 2 {
+		t.Errorf("archived rows = %d, want 2 (dust and emptied)", archived)
+	}
+
+	// The dust address turns active again: the upsert creates a fresh row
+	// and the next pass folds the archived 5 back in.
+	updateBalance(dust, big.NewInt(50), 200)
+	pruneDustHolders(10, true)
+	if got := getBalance(t, dust); got != 55 {
+		t.Errorf("restored balance = %d, want 55", got)
+	}
+	if err := db.QueryRow(q(`SELECT COUNT(*) FROM wbtc_holders_archive WHERE address = ?`), dust).Scan(&archived); err != nil {
+		t.Fatalf("count archive: %v", err)
+	}
+	if archived != 0 {
+		t.Errorf("dust address still archived after restore")
+	}
+}
+
+func TestPruneDustHoldersDeleteMode(t *testing.T) {
+	setupTestDB(t)
+
+	gone := common.HexToAddress("0x05").Hex()
+	kept := common.HexToAddress("0x06").Hex()
+	setBalance(gone, big.NewInt(0), 100)
+	setBalance(kept, big.NewInt(11), 100)
+
+	pruneDustHolders(10, false)
+
+	var holders, archived int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM wbtc_holders`).Scan(&holders); err != nil {
+		t.Fatalf("count holders: %v", err)
+	}
+	if err := db.QueryRow(`SELECT COUNT(*) FROM wbtc_holders_archive`).Scan(&archived); err != nil {
+		t.Fatalf("count archive: %v", err)
+	}
+	if holders != 1 || archived != 0 {
+		t.Errorf("delete mode left %d holders and %d archive rows, want 1 and 0", holders, archived)
+	}
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// ENS enrichment. ENS_REFRESH_INTERVAL enables a background pass that
+// reverse-resolves the top holders' addresses (<addr>.addr.reverse through
+// the registry) and stores the names in ens_names, so the holder endpoints
+// and the JSON export can show "vitalik.eth" next to the hex. Names are
+// cosmetic: reverse records are self-asserted and no forward check is
+// done, so nothing downstream may treat them as an identity — payouts and
+// balances never touch this table. Unset or 0 disables the pass entirely;
+// lookups then simply find no rows.
+const ensRegistryDefault = "0x00000000000C2E074eC69A0dBb2997BA44C28102"
+
+const ensCallABI = `[
+	{"constant":true,"inputs":[{"name":"node","type":"bytes32"}],"name":"resolver","outputs":[{"name":"","type":"address"}],"stateMutability":"view","type":"function"},
+	{"constant":true,"inputs":[{"name":"node","type":"bytes32"}],"name":"name","outputs":[{"name":"","type":"string"}],"stateMutability":"view","type":"function"}
+]`
+
+// ensNamehash implements EIP-137 namehash over labels given leftmost
+// first: ensNamehash("addr", "reverse") is namehash("addr.reverse").
+func ensNamehash(labels ...string) common.Hash {
+	node := common.Hash{}
+	for i := len(labels) - 1; i >= 0; i-- {
+		node = crypto.Keccak256Hash(node.Bytes(), crypto.Keccak256([]byte(labels[i])))
+	}
+	return node
+}
+
+func ensReverseNode(address string) common.Hash {
+	return ensNamehash(strings.ToLower(strings.TrimPrefix(address, "0x")), "addr", "reverse")
+}
+
+// resolveENSName reverse-resolves one address: resolver(node) on the
+// registry, then name(node) on that resolver. An address without a reverse
+// record returns "" with no error.
+func resolveENSName(address string) (string, error) {
+	callABI, err := abi.JSON(strings.NewReader(ensCallABI))
+	if err != nil {
+		return "", fmt.Errorf("parse ENS ABI: %w", err)
+	}
+	node := ensReverseNode(address)
+
+	registry := common.HexToAddress(ensRegistryDefault)
+	if override := os.Getenv("ENS_REGISTRY"); override != "" {
+		registry = common.HexToAddress(override)
+	}
+	data, err := callABI.Pack("resolver", node)
+	if err != nil {
+		return "", fmt.Errorf("pack resolver call: %w", err)
+	}
+	result, err := currentEthClient().CallContract(context.Background(), ethereum.CallMsg{To: &registry, Data: data}, nil)
+	if err != nil {
+		return "", fmt.Errorf("call resolver(): %w", err)
+	}
+	out, err := callABI.Unpack("resolver", result)
+	if err != nil {
+		return "", fmt.Errorf("unpack resolver(): %w", err)
+	}
+	resolver, ok := out[0].(common.Address)
+	if !ok {
+		return "", fmt.Errorf("resolver() returned unexpected type %T", out[0])
+	}
+	if resolver == (common.Address{}) {
+		return "", nil
+	}
+
+	data, err = callABI.Pack("name", node)
+	if err != nil {
+		return "", fmt.Errorf("pack name call: %w", err)
+	}
+	result, err = currentEthClient().CallContract(context.Background(), ethereum.CallMsg{To: &resolver, Data: data}, nil)
+	if err != nil {
+		return "", fmt.Errorf("call name(): %w", err)
+	}
+	out, err = callABI.Unpack("name", result)
+	if err != nil {
+		return "", fmt.Errorf("unpack name(): %w", err)
+	}
+	name, ok := out[0].(string)
+	if !ok {
+		return "", fmt.Errorf("name() returned unexpected type %T", out[0])
+	}
+	return name, nil
+}
+
+// refreshENSNames resolves the top holders by balance — the addresses
+// people actually look at — bounded by ENS_REFRESH_BATCH per pass. Stale
+// names are replaced, dropped reverse records are deleted.
+func refreshENSNames(batch uint64) {
+	expr := balanceExpr()
+	rows, err := readDB.Query(q("SELECT address FROM wbtc_holders WHERE "+expr+" != 0 ORDER BY "+expr+" DESC LIMIT ?"), batch)
+	if err != nil {
+		log.Printf("ENS refresh: failed to list holders: %v", err)
+		return
+	}
+	var addresses []string
+	for rows.Next() {
+		var address string
+		if err := rows.Scan(&address); err != nil {
+			rows.Close()
+			log.Printf("ENS refresh: failed to scan holder: %v", err)
+			return
+		}
+		addresses = append(addresses, address)
+	}
+	rows.Close()
+
+	resolved, failed := 0, 0
+	now := time.Now().Unix()
+	for _, address := range addresses {
+		if stopRequested.Load() {
+			return
+		}
+		name, err := resolveENSName(address)
+		if err != nil {
+			failed++
+			continue
+		}
+		if name == "" {
+			if _, err := db.Exec(q(`DELETE FROM ens_names WHERE address = ?`), address); err != nil {
+				log.Printf("ENS refresh: failed to drop name for %s: %v", address, err)
+			}
+			continue
+		}
+		resolved++
+		_, err = db.Exec(q(`INSERT INTO ens_names (address, name, resolved_at) VALUES (?, ?, ?)
+			ON CONFLICT(address) DO UPDATE SET name = excluded.name, resolved_at = excluded.resolved_at`),
+			address, name, now)
+		if err != nil {
+			log.Printf("ENS refresh: failed to store name for %s: %v", address, err)
+		}
+	}
+	log.Printf("ENS refresh: %d of %d top holders have reverse records (%d lookups failed)", resolved, len(addresses), failed)
+}
+
+// runENSRefresh is the enrichment loop, started once from main.
+func runENSRefresh() {
+	interval := envDuration("ENS_REFRESH_INTERVAL", 0)
+	if interval <= 0 {
+		return
+	}
+	batch := envUint("ENS_REFRESH_BATCH", 200)
+	log.Printf("ENS enrichment enabled: top %d holders every %s", batch, interval)
+	for {
+		refreshENSNames(batch)
+		sleepOrShutdown(interval)
+		if stopRequested.Load() {
+			return
+		}
+	}
+}
+
+// ensNameFor returns the stored name for an address, "" when none.
+func ensNameFor(address string) string {
+	var name string
+	err := readDB.QueryRow(q(`SELECT name FROM ens_names WHERE address = ?`), address).Scan(&name)
+	if err == sql.ErrNoRows {
+		return ""
+	}
+	if err != nil {
+		log.Printf("Failed to read ENS name for %s: %v", address, err)
+		return ""
+	}
+	return name
+}
+
+// loadENSNames reads the whole table into a map for bulk emitters like the
+// export; it is bounded by what the refresh pass resolves.
+func loadENSNames() map[string]string {
+	rows, err := readDB.Query(`SELECT address, name FROM ens_names`)
+	if err != nil {
+		log.Printf("Failed to read ENS names: %v", err)
+		return nil
+	}
+	defer rows.Close()
+	names := map[string]string{}
+	for rows.Next() {
+		var address, name string
+		if err := rows.Scan(&address, &name); err != nil {
+			log.Printf("Failed to scan ENS name: %v", err)
+			return names
+		}
+		names[address] = name
+	}
+	return names
+}
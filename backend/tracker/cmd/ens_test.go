@@ -0,0 +1,20 @@
+package main
+
+import "testing"
+
+// Vectors from EIP-137 and the reverse-registrar spec.
+func TestENSNamehash(t *testing.T) {
+	tests := []struct {
+		labels []string
+		want   string
+	}{
+		{[]string{"eth"}, "0x93cdeb708b7545dc668eb9280176169d1c33cfd8ed6f04690a0bcc88a93fc4ae"},
+		{[]string{"foo", "eth"}, "0xde9b09fd7c5f901e23a3f19fecc54828e9c848539801e86591bd9801b019f84f"},
+		{[]string{"addr", "reverse"}, "0x91d1777781884d03a6757a803996e38de2a42967fb37eeaca72729271025a9e2"},
+	}
+	for _, test := range tests {
+		if got := ensNamehash(test.labels...).Hex(); got != test.want {
+			t.Errorf("ensNamehash(%v) = %s, want %s", test.labels, got, test.want)
+		}
+	}
+}
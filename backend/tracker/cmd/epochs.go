@@ -0,0 +1,140 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"math/big"
+	"net/http"
+	"strconv"
+
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// The bridge contract periodically emits EpochRolled(epoch, blockNumber).
+// Indexing these lets governance reference "balances at epoch N" without
+// anyone having to know block numbers. Enable with EVENTS=transfer,burn,epoch.
+func handleEpochRolledEvent(vLog types.Log) {
+	var epochEvent struct {
+		Epoch       *big.Int
+		BlockNumber *big.Int
+	}
+	if err := decodeEvent("EpochRolled", &epochEvent, vLog); err != nil {
+		recordDecodeFailure("EpochRolled", vLog)
+		log.Printf("Skipping undecodable EpochRolled log (tx %s, index %d): %v", vLog.TxHash.Hex(), vLog.Index, err)
+		return
+	}
+	_, err := db.Exec(q(`INSERT INTO epochs (epoch, block_number) VALUES (?, ?)
+		ON CONFLICT(epoch) DO UPDATE SET block_number = excluded.block_number`),
+		epochEvent.Epoch.Int64(), epochEvent.BlockNumber.Int64())
+	if err != nil {
+		log.Printf("Failed to record epoch %s: %v", epochEvent.Epoch, err)
+		return
+	}
+	log.Printf("EpochRolled: epoch %s at block %s", epochEvent.Epoch, epochEvent.BlockNumber)
+}
+
+func epochBlock(epoch uint64) (uint64, error) {
+	var block uint64
+	err := readDB.QueryRow(q("SELECT block_number FROM epochs WHERE epoch = ?"), epoch).Scan(&block)
+	return block, err
+}
+
+// supplyAtBlock recomputes total supply as of a block from the ledger:
+// everything minted out of the zero address minus everything sent back.
+// Accumulated in Go as big integers; a SQL SUM would round beyond int64 on
+// sqlite.
+func supplyAtBlock(block uint64) (*big.Int, error) {
+	rows, err := readDB.Query(q(`SELECT from_address, to_address, value
+		FROM transfers WHERE event = 'Transfer' AND (from_address = ? OR to_address = ?) AND block <= ?`),
+		zeroAddress, zeroAddress, block)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	supply := new(big.Int)
+	value := new(big.Int)
+	for rows.Next() {
+		var from, to, stored string
+		if err := rows.Scan(&from, &to, &stored); err != nil {
+			return nil, err
+		}
+		if _, ok := value.SetString(stored, 10); !ok {
+			return nil, fmt.Errorf("corrupt ledger value %q", stored)
+		}
+		if from == zeroAddress {
+			supply.Add(supply, value)
+		}
+		if to == zeroAddress {
+			supply.Sub(supply, value)
+		}
+	}
+	return supply, rows.Err()
+}
+
+func resolveEpochParam(w http.ResponseWriter, r *http.Request) (epoch, block uint64, ok bool) {
+	epoch, err := strconv.ParseUint(r.URL.Query().Get("epoch"), 10, 64)
+	if err != nil {
+		http.Error(w, "epoch query parameter required", http.StatusBadRequest)
+		return 0, 0, false
+	}
+	block, err = epochBlock(epoch)
+	if err == sql.ErrNoRows {
+		http.Error(w, "unknown epoch", http.StatusNotFound)
+		return 0, 0, false
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return 0, 0, false
+	}
+	return epoch, block, true
+}
+
+// handleSupply serves GET /supply?epoch=N.
+func handleSupply(w http.ResponseWriter, r *http.Request) {
+	if !ledgerEnabled {
+		http.Error(w, "supply queries need the transfer ledger (TRACK_TRANSFERS)", http.StatusNotFound)
+		return
+	}
+	epoch, block, ok := resolveEpochParam(w, r)
+	if !ok {
+		return
+	}
+	supply, err := supplyAtBlock(block)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, map[string]interface{}{
+		"epoch":  epoch,
+		"block":  block,
+		"supply": supply.String(),
+	})
+}
+
+// handleHoldersSnapshot serves GET /holders/snapshot?epoch=N: the full
+// holder set reconstructed from the ledger as of the epoch's block.
+func handleHoldersSnapshot(w http.ResponseWriter, r *http.Request) {
+	if !ledgerEnabled {
+		http.Error(w, "snapshots need the transfer ledger (TRACK_TRANSFERS)", http.StatusNotFound)
+		return
+	}
+	epoch, block, ok := resolveEpochParam(w, r)
+	if !ok {
+		return
+	}
+	reconstructed, err := ledgerHoldersAt(block)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	holders := make(map[string]string, len(reconstructed))
+	for address, balance := range reconstructed {
+		holders[address] = balance.String()
+	}
+	writeJSON(w, map[string]interface{}{
+		"epoch":   epoch,
+		"block":   block,
+		"holders": holders,
+	})
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"log"
+	"os"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// Which events the tracker processes is driven by the EVENTS config
+// (e.g. "transfer,burn") instead of a hardcoded pair, so deployments that
+// don't need burns can skip the writes and speed up backfill. The topic
+// filter and handler dispatch are both built from this list.
+
+// EventHandler is the unit of dispatch: anything registered for a log's
+// Topics[0] gets the log. The built-in indexing handlers are plain
+// functions wrapped in EventHandlerFunc; an integration (analytics sink,
+// compliance hook) implements the interface and registers itself with
+// registerEventHandler, without touching the loop.
+type EventHandler interface {
+	HandleLog(vLog types.Log)
+}
+
+// EventHandlerFunc adapts a function to the EventHandler interface.
+type EventHandlerFunc func(types.Log)
+
+func (f EventHandlerFunc) HandleLog(vLog types.Log) { f(vLog) }
+
+var (
+	enabledEvents []string
+	enabledTopics []common.Hash
+	eventHandlers = map[common.Hash][]EventHandler{}
+)
+
+// registerEventHandler attaches a handler to a topic hash, adding the
+// topic to the RPC log filter when it is new. Handlers for the same topic
+// run in registration order; the built-ins register first in initEvents,
+// so a custom hook observes state the indexer has already applied. Call
+// before the loop starts — the registry is not mutated concurrently.
+func registerEventHandler(topic common.Hash, handler EventHandler) {
+	if _, known := eventHandlers[topic]; !known {
+		enabledTopics = append(enabledTopics, topic)
+	}
+	eventHandlers[topic] = append(eventHandlers[topic], handler)
+}
+
+// topicEnabled reports whether a topic is part of the RPC log filter.
+func topicEnabled(topic common.Hash) bool {
+	_, known := eventHandlers[topic]
+	return known
+}
+
+// eventAliases maps the short config names onto ABI event names.
+var eventAliases = map[string]string{
+	"transfer": "Transfer",
+	"burn":     "TokensBurned",
+	"mint":     "TokensMinted",
+	"epoch":    "EpochRolled",
+	"deposit":  "BridgeDeposit",
+	"withdraw": "BridgeWithdraw",
+	"approval": "Approval",
+}
+
+func initEvents() {
+	handlersByName := map[string]func(types.Log){
+		"Transfer":       handleTransferEvent,
+		"TokensBurned":   handleTokensBurnedEvent,
+		"TokensMinted":   handleTokensMintedEvent,
+		"EpochRolled":    handleEpochRolledEvent,
+		"BridgeDeposit":  handleBridgeDepositEvent,
+		"BridgeWithdraw": handleBridgeWithdrawEvent,
+		"Approval":       handleApprovalEvent,
+	}
+
+	spec := os.Getenv("EVENTS")
+	if spec == "" {
+		spec = "transfer,burn"
+	}
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		name, ok := eventAliases[strings.ToLower(item)]
+		if !ok {
+			for abiName := range contractABI.Events {
+				if strings.EqualFold(abiName, item) {
+					name, ok = abiName, true
+					break
+				}
+			}
+		}
+		if !ok {
+			log.Fatalf("EVENTS lists %q, which is not in the ABI", item)
+		}
+		handler, ok := handlersByName[name]
+		if !ok {
+			log.Fatalf("EVENTS lists %q, which has no handler", name)
+		}
+		id := contractABI.Events[name].ID
+		if _, dup := eventHandlers[id]; dup {
+			continue
+		}
+		registerEventHandler(id, EventHandlerFunc(handler))
+		enabledEvents = append(enabledEvents, name)
+	}
+	if len(enabledEvents) == 0 {
+		log.Fatalf("EVENTS %q enables no events", spec)
+	}
+	// Always watch for proxy implementation changes; the event is free to
+	// filter for and missing one silently is how decoding breaks mid-chain.
+	registerEventHandler(upgradedTopic, EventHandlerFunc(handleUpgradedEvent))
+	log.Printf("Indexing events: %s", strings.Join(enabledEvents, ", "))
+}
+
+// eventEnabled reports whether the named ABI event is in the configured
+// EVENTS set.
+func eventEnabled(name string) bool {
+	for _, enabled := range enabledEvents {
+		if enabled == name {
+			return true
+		}
+	}
+	return false
+}
+
+// dispatchLog routes a fetched log to its topic's handlers; logs for
+// events outside the configured set are ignored, and pairs already
+// recorded in processed_events are skipped so replayed windows apply
+// exactly once.
+func dispatchLog(vLog types.Log) {
+	if len(vLog.Topics) == 0 {
+		return
+	}
+	handlers, ok := eventHandlers[vLog.Topics[0]]
+	if !ok {
+		return
+	}
+	if !markEventProcessed(vLog) {
+		log.Printf("Skipping already-processed log (tx %s, index %d, block %d)", vLog.TxHash.Hex(), vLog.Index, vLog.BlockNumber)
+		return
+	}
+	if mempoolEnabled {
+		clearPendingTransfer(vLog.TxHash)
+	}
+	for _, handler := range handlers {
+		handler.HandleLog(vLog)
+	}
+}
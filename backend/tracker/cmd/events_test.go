@@ -0,0 +1,47 @@
+package main
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// A custom handler registered for an already-handled topic must run after
+// the built-in one, observing the state it left behind.
+func TestRegisterEventHandlerRunsAfterBuiltin(t *testing.T) {
+	initTestABI(t)
+	if len(eventHandlers) == 0 {
+		initEvents()
+	}
+	setupTestDB(t)
+
+	const alice = "0x0000000000000000000000000000000000000a11"
+	transferID := contractABI.Events["Transfer"].ID
+	saved := eventHandlers[transferID]
+	defer func() { eventHandlers[transferID] = saved }()
+
+	var observed string
+	registerEventHandler(transferID, EventHandlerFunc(func(vLog types.Log) {
+		observed = getBalanceText(t, common.HexToAddress(alice).Hex())
+	}))
+
+	dispatchLog(makeTransferLog(t, zeroAddress, alice, big.NewInt(70), 3, "0x99", 0))
+	if observed != "70" {
+		t.Errorf("custom handler observed balance %q, want 70 (after the built-in applied)", observed)
+	}
+}
+
+func TestRegisterEventHandlerNewTopicJoinsFilter(t *testing.T) {
+	topic := common.HexToHash("0x1111111111111111111111111111111111111111111111111111111111111111")
+	defer func() {
+		delete(eventHandlers, topic)
+		enabledTopics = enabledTopics[:len(enabledTopics)-1]
+	}()
+	before := len(enabledTopics)
+	registerEventHandler(topic, EventHandlerFunc(func(types.Log) {}))
+	if len(enabledTopics) != before+1 {
+		t.Errorf("enabledTopics grew by %d, want 1", len(enabledTopics)-before)
+	}
+}
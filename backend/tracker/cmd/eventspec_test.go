@@ -0,0 +1,77 @@
+package main
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func TestRegisterEventSpecsIndexesConfiguredEvent(t *testing.T) {
+	setupTestDB(t)
+
+	var specs []eventSpec
+	if err := json.Unmarshal([]byte(`[{
+		"name": "RewardPaid",
+		"inputs": [
+			{"name": "user", "type": "address", "indexed": true},
+			{"name": "amount", "type": "uint256"}
+		],
+		"handler": "record",
+		"amount_field": "amount"
+	}]`), &specs); err != nil {
+		t.Fatalf("unmarshal specs: %v", err)
+	}
+	if err := registerEventSpecs(specs); err != nil {
+		t.Fatalf("register specs: %v", err)
+	}
+
+	topic := customEventABI.Events["RewardPaid"].ID
+	found := false
+	for _, enabled := range enabledTopics {
+		if enabled == topic {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("spec'd topic %s did not join the log filter", topic.Hex())
+	}
+
+	user := common.HexToAddress("0x00000000000000000000000000000000000000cd")
+	amount := make([]byte, 32)
+	big.NewInt(777).FillBytes(amount)
+	dispatchLog(types.Log{
+		Topics:      []common.Hash{topic, common.BytesToHash(user.Bytes())},
+		Data:        amount,
+		BlockNumber: 42,
+		TxHash:      common.HexToHash("0xe1"),
+		Index:       3,
+	})
+
+	var event, fields, stored string
+	err := db.QueryRow(q(`SELECT event, fields, amount FROM custom_events WHERE tx_hash = ?`),
+		common.HexToHash("0xe1").Hex()).Scan(&event, &fields, &stored)
+	if err != nil {
+		t.Fatalf("read custom event: %v", err)
+	}
+	if event != "RewardPaid" || stored != "777" {
+		t.Errorf("custom event = %s amount %s", event, stored)
+	}
+	var decoded map[string]string
+	if err := json.Unmarshal([]byte(fields), &decoded); err != nil {
+		t.Fatalf("unmarshal fields: %v", err)
+	}
+	if decoded["user"] != user.Hex() || decoded["amount"] != "777" {
+		t.Errorf("decoded fields = %v", decoded)
+	}
+}
+
+func TestRegisterEventSpecsRejectsUnknownHandler(t *testing.T) {
+	setupTestDB(t)
+	specs := []eventSpec{{Name: "Oops", Handler: "credit_balance"}}
+	if err := registerEventSpecs(specs); err == nil {
+		t.Errorf("unknown handler type accepted")
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"strings"
+)
+
+// handleHoldersExportJSON serves GET /holders/export.json: a JSON array of
+// all holders ordered by balance, streamed row-by-row so memory stays
+// bounded for huge tables, with gzip negotiated via Accept-Encoding.
+// Balances are emitted as strings so consumers never lose precision to
+// float parsing.
+func handleHoldersExportJSON(w http.ResponseWriter, r *http.Request) {
+	expr := balanceExpr()
+	rows, err := readDB.Query(q("SELECT address, balance FROM wbtc_holders WHERE "+expr+" != 0 AND "+expr+" >= ? ORDER BY "+expr+" DESC"), minHolderBalance)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	w.Header().Set("Content-Type", "application/json")
+	var out io.Writer = w
+	if strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+		w.Header().Set("Content-Encoding", "gzip")
+		gz := gzip.NewWriter(w)
+		defer gz.Close()
+		out = gz
+	}
+
+	ensNames := loadENSNames()
+	labels := loadAddressLabels()
+	io.WriteString(out, "[")
+	first := true
+	for rows.Next() {
+		var address, balance string
+		if err := rows.Scan(&address, &balance); err != nil {
+			// The array is already partially written; all we can do is
+			// truncate the stream so the consumer sees invalid JSON rather
+			// than silently short data.
+			return
+		}
+		if !first {
+			io.WriteString(out, ",")
+		}
+		first = false
+		row := map[string]string{
+			"address": address,
+			"balance": balance,
+		}
+		if parsed, ok := new(big.Int).SetString(balance, 10); ok {
+			row["balance_formatted"] = formatUnits(parsed, tokenDecimals())
+		}
+		if name, ok := ensNames[address]; ok {
+			row["ens_name"] = name
+		}
+		if label, ok := labels[address]; ok {
+			row["label"] = label
+		}
+		entry, _ := json.Marshal(row)
+		out.Write(entry)
+	}
+	if err := rows.Err(); err != nil {
+		return
+	}
+	fmt.Fprint(out, "]\n")
+}
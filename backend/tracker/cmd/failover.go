@@ -0,0 +1,213 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// RPC failover. ETHEREUM_NODE_URL accepts a comma-separated list of
+// endpoints; the first is the primary and the rest are fallbacks in
+// preference order. The indexing loops feed a health score — consecutive
+// failed calls on the active endpoint — and once it crosses
+// rpcFailureThreshold the tracker switches to the next endpoint that
+// responds and is on the right chain, so a single provider outage no
+// longer halts the bridge. While a fallback is active the primary is
+// re-tried every PRIMARY_RETRY_INTERVAL (default 5m) and wins back as soon
+// as it recovers. With a single URL configured all of this is inert and
+// behavior is unchanged.
+
+// rpcFailureThreshold is how many consecutive failed calls the active
+// endpoint gets before the pool rotates. One flaky call never swaps.
+const rpcFailureThreshold = 3
+
+// rpcEndpoint caches its dialed client so a fail-back reuses the existing
+// connection and replaced clients are never closed under a handler that is
+// still holding them.
+type rpcEndpoint struct {
+	url    string
+	client *ethclient.Client
+}
+
+var rpcPool struct {
+	sync.Mutex
+	endpoints []*rpcEndpoint
+	active    int
+	// failures counts consecutive failed calls on the active endpoint,
+	// reset by any successful log fetch.
+	failures         int
+	lastPrimaryRetry time.Time
+}
+
+var rpcFailovers = promauto.NewCounter(prometheus.CounterOpts{
+	Name: "tracker_rpc_failovers_total",
+	Help: "Number of times the tracker switched to a different RPC endpoint.",
+})
+
+func splitNodeURLs(raw string) []string {
+	var urls []string
+	for _, url := range strings.Split(raw, ",") {
+		if url = strings.TrimSpace(url); url != "" {
+			urls = append(urls, url)
+		}
+	}
+	return urls
+}
+
+// dialEthClient builds the endpoint pool from ETHEREUM_NODE_URL and
+// connects to the first entry that dials. Dialing an HTTP endpoint is
+// lazy, so a provider that is merely down still "connects" here and gets
+// failed away from by the health score once its calls start erroring; this
+// loop only skips entries that are malformed outright.
+func dialEthClient() *ethclient.Client {
+	urls := splitNodeURLs(os.Getenv("ETHEREUM_NODE_URL"))
+	for _, url := range urls {
+		rpcPool.endpoints = append(rpcPool.endpoints, &rpcEndpoint{url: url})
+	}
+	if len(urls) > 1 {
+		log.Printf("RPC failover enabled across %d endpoints (primary %s)", len(urls), urls[0])
+	}
+	for i, endpoint := range rpcPool.endpoints {
+		client, err := dialEthEndpoint(endpoint.url)
+		if err != nil {
+			log.Printf("Failed to connect to Ethereum node %s: %v", endpoint.url, err)
+			continue
+		}
+		endpoint.client = client
+		rpcPool.active = i
+		ethClient.Store(client)
+		return client
+	}
+	log.Fatalf("Failed to connect to any Ethereum node in ETHEREUM_NODE_URL=%q", os.Getenv("ETHEREUM_NODE_URL"))
+	return nil
+}
+
+// activeRPCEndpointURL returns the URL of the endpoint currently serving
+// calls, for jobs that need their own *rpc.Client (batch calls) rather
+// than the shared ethclient. Falls back to the raw env var before the
+// pool exists so offline tools keep working.
+func activeRPCEndpointURL() string {
+	rpcPool.Lock()
+	defer rpcPool.Unlock()
+	if len(rpcPool.endpoints) == 0 {
+		return os.Getenv("ETHEREUM_NODE_URL")
+	}
+	return rpcPool.endpoints[rpcPool.active].url
+}
+
+// activeEthClient returns the client the indexing loops should use for
+// their next round of calls, first applying any failover or primary
+// fail-back the health score calls for. Loops re-read it every iteration,
+// so a swap takes effect at the next window rather than mid-flight.
+func activeEthClient() *ethclient.Client {
+	rpcPool.Lock()
+	defer rpcPool.Unlock()
+	if rpcPool.failures >= rpcFailureThreshold && len(rpcPool.endpoints) > 1 {
+		failOverLocked()
+	}
+	if rpcPool.active != 0 && time.Since(rpcPool.lastPrimaryRetry) >= envDuration("PRIMARY_RETRY_INTERVAL", 5*time.Minute) {
+		rpcPool.lastPrimaryRetry = time.Now()
+		primary := rpcPool.endpoints[0]
+		if err := adoptEndpointLocked(0); err != nil {
+			log.Printf("Primary RPC endpoint %s still unhealthy: %v", primary.url, err)
+		} else {
+			log.Printf("Primary RPC endpoint %s recovered; switching back from fallback", primary.url)
+		}
+	}
+	return ethClient.Load()
+}
+
+// currentEthClient returns the last adopted client without applying any
+// failover policy — the accessor for one-shot calls from handlers and
+// background jobs. The indexing loops use activeEthClient instead so their
+// next window picks up swaps and primary fail-backs.
+func currentEthClient() *ethclient.Client {
+	return ethClient.Load()
+}
+
+// failOverLocked rotates to the first usable endpoint after the active
+// one, wrapping around. When every alternative is down too the score
+// resets so the active endpoint gets another threshold's worth of calls
+// before the pool rescans.
+func failOverLocked() {
+	from := rpcPool.endpoints[rpcPool.active]
+	for offset := 1; offset < len(rpcPool.endpoints); offset++ {
+		i := (rpcPool.active + offset) % len(rpcPool.endpoints)
+		if err := adoptEndpointLocked(i); err != nil {
+			log.Printf("RPC endpoint %s unusable: %v", rpcPool.endpoints[i].url, err)
+			continue
+		}
+		rpcFailovers.Inc()
+		log.Printf("RPC failover: %s -> %s after %d consecutive failures", from.url, rpcPool.endpoints[i].url, rpcFailureThreshold)
+		return
+	}
+	log.Printf("RPC failover: no usable alternative to %s; staying put", from.url)
+	rpcPool.failures = 0
+}
+
+// adoptEndpointLocked dials the endpoint if needed, proves it is live and
+// on the right chain, and makes it active. Per verifyChainID's contract,
+// every endpoint the failover path adopts gets the chain check again — a
+// fallback on the wrong network would index garbage without a single
+// error. The ChainID call doubles as the liveness probe.
+func adoptEndpointLocked(i int) error {
+	endpoint := rpcPool.endpoints[i]
+	if endpoint.client == nil {
+		client, err := dialEthEndpoint(endpoint.url)
+		if err != nil {
+			return err
+		}
+		endpoint.client = client
+	}
+	if err := checkEndpointChain(endpoint.client); err != nil {
+		return err
+	}
+	rpcPool.active = i
+	rpcPool.failures = 0
+	ethClient.Store(endpoint.client)
+	return nil
+}
+
+// checkEndpointChain is the non-fatal counterpart of verifyChainID for
+// runtime swaps: the reference chain is EXPECTED_CHAIN_ID when set,
+// otherwise the chain the tracker connected to at startup.
+func checkEndpointChain(client *ethclient.Client) error {
+	ctx, cancel := context.WithTimeout(rootCtx, 10*time.Second)
+	defer cancel()
+	id, err := client.ChainID(ctx)
+	if err != nil {
+		return err
+	}
+	expected := os.Getenv("EXPECTED_CHAIN_ID")
+	if expected == "" {
+		expected = chainIDValue
+	}
+	if expected != "" && id.String() != expected {
+		return fmt.Errorf("reports chain %s, want %s", id.String(), expected)
+	}
+	return nil
+}
+
+// noteRPCFailure and noteRPCSuccess are the health-score feed from the
+// indexing loops. Success is only reported from the log-fetch path, not
+// the head fetch: an endpoint that serves heads but caps or errors every
+// FilterLogs call is useless for indexing and must still fail over.
+func noteRPCFailure() {
+	rpcPool.Lock()
+	rpcPool.failures++
+	rpcPool.Unlock()
+}
+
+func noteRPCSuccess() {
+	rpcPool.Lock()
+	rpcPool.failures = 0
+	rpcPool.Unlock()
+}
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitNodeURLs(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want []string
+	}{
+		{"https://a.example", []string{"https://a.example"}},
+		{"https://a.example,https://b.example", []string{"https://a.example", "https://b.example"}},
+		{" https://a.example , https://b.example ", []string{"https://a.example", "https://b.example"}},
+		{"https://a.example,,https://b.example,", []string{"https://a.example", "https://b.example"}},
+		{"", nil},
+	}
+	for _, test := range tests {
+		if got := splitNodeURLs(test.raw); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("splitNodeURLs(%q) = %v, want %v", test.raw, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+	"net/http"
+	"sort"
+)
+
+// Depth-1 flow tracing over the transfer ledger: who did X send to, who sent
+// to X, with aggregate amounts. Served as /holders/{address}/recipients and
+// /holders/{address}/senders; an optional from=/to= block range narrows the
+// window, and limit=/offset= page through large counterparty sets.
+
+// flowsPageLimit caps one page of counterparties.
+const flowsPageLimit = 500
+
+type flowCounterparty struct {
+	Address   string `json:"address"`
+	Transfers int64  `json:"transfers"`
+	Total     string `json:"total"`
+}
+
+// handleHolderFlows serves both directions; direction is "recipients" or
+// "senders" and decides which ledger column we group by. Totals accumulate
+// as big integers in Go — a SQL SUM over the text value column would either
+// fail or round — so grouping, ordering, and paging happen here too.
+func handleHolderFlows(w http.ResponseWriter, r *http.Request, address, direction string) {
+	if !ledgerEnabled {
+		http.Error(w, "flow queries need the transfer ledger (TRACK_TRANSFERS)", http.StatusNotFound)
+		return
+	}
+	fromBlock := envQueryUint(r, "from", 0)
+	toBlock := envQueryUint(r, "to", maxBlockParam)
+	limit := envQueryUint(r, "limit", 100)
+	if limit > flowsPageLimit {
+		limit = flowsPageLimit
+	}
+	offset := envQueryUint(r, "offset", 0)
+
+	matchColumn, groupColumn := "from_address", "to_address"
+	if direction == "senders" {
+		matchColumn, groupColumn = "to_address", "from_address"
+	}
+	rows, err := readDB.Query(q(`SELECT `+groupColumn+`, value
+		FROM transfers WHERE event = 'Transfer' AND `+matchColumn+` = ? AND block >= ? AND block <= ?`),
+		address, fromBlock, toBlock)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	totals := map[string]*big.Int{}
+	transfers := map[string]int64{}
+	value := new(big.Int)
+	for rows.Next() {
+		var counterparty, stored string
+		if err := rows.Scan(&counterparty, &stored); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if _, ok := value.SetString(stored, 10); !ok {
+			http.Error(w, fmt.Sprintf("corrupt ledger value %q", stored), http.StatusInternalServerError)
+			return
+		}
+		total, ok := totals[counterparty]
+		if !ok {
+			total = new(big.Int)
+			totals[counterparty] = total
+		}
+		total.Add(total, value)
+		transfers[counterparty]++
+	}
+	if err := rows.Err(); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	addresses := make([]string, 0, len(totals))
+	for a := range totals {
+		addresses = append(addresses, a)
+	}
+	sort.Slice(addresses, func(i, j int) bool {
+		if c := totals[addresses[i]].Cmp(totals[addresses[j]]); c != 0 {
+			return c > 0
+		}
+		return addresses[i] < addresses[j]
+	})
+	if offset > uint64(len(addresses)) {
+		offset = uint64(len(addresses))
+	}
+	addresses = addresses[offset:]
+	if limit < uint64(len(addresses)) {
+		addresses = addresses[:limit]
+	}
+
+	counterparties := make([]flowCounterparty, 0, len(addresses))
+	for _, a := range addresses {
+		counterparties = append(counterparties, flowCounterparty{
+			Address:   a,
+			Transfers: transfers[a],
+			Total:     totals[a].String(),
+		})
+	}
+	writeJSON(w, map[string]interface{}{
+		"address":   address,
+		"direction": direction,
+		"limit":     limit,
+		"offset":    offset,
+		direction:   counterparties,
+	})
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Flow queries share the block-range defaults with the other holder routes,
+// so they too must answer a request that sets no query parameters.
+func TestHolderFlowsRouteWithoutQueryParams(t *testing.T) {
+	initTestABI(t)
+	setupTestDB(t)
+	t.Setenv("TRACK_TRANSFERS", "true")
+	initLedger()
+	defer func() { ledgerEnabled = false }()
+
+	const (
+		alice = "0x0000000000000000000000000000000000000a11"
+		bob   = "0x0000000000000000000000000000000000000b0b"
+	)
+	handleTransferEvent(makeTransferLog(t, zeroAddress, alice, big.NewInt(40), 1, "0x01", 0))
+	handleTransferEvent(makeTransferLog(t, alice, bob, big.NewInt(7), 2, "0x02", 0))
+	handleTransferEvent(makeTransferLog(t, alice, bob, big.NewInt(3), 3, "0x03", 0))
+
+	rec := httptest.NewRecorder()
+	holdersRouter(rec, httptest.NewRequest("GET", "/holders/"+alice+"/recipients", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /recipients without query params = %d, want 200: %s", rec.Code, rec.Body.String())
+	}
+	var resp struct {
+		Recipients []flowCounterparty `json:"recipients"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	wantBob := common.HexToAddress(bob).Hex()
+	if len(resp.Recipients) != 1 || resp.Recipients[0].Address != wantBob {
+		t.Fatalf("recipients = %+v, want exactly %s", resp.Recipients, wantBob)
+	}
+	if resp.Recipients[0].Transfers != 2 || resp.Recipients[0].Total != "10" {
+		t.Errorf("counterparty = %+v, want 2 transfers totalling 10", resp.Recipients[0])
+	}
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"log"
+	"math/big"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Operator gas watching. The minter account pays gas for every mint; when
+// it runs dry the bridge stops minting with no error anywhere — deposits
+// simply stop confirming. With MINTER_ADDRESS set, the tracker samples the
+// account's ETH balance every GAS_WATCH_INTERVAL (default 5m), records the
+// series in operator_gas for trend dashboards, and raises a notification
+// whenever the balance sits below GAS_ALERT_THRESHOLD wei. The alert fires
+// once per crossing — rearming only after the balance recovers — so a slow
+// refill doesn't page every five minutes.
+var (
+	operatorGasWei = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "tracker_operator_gas_wei",
+		Help: "Last observed ETH balance of the minter account, in wei.",
+	})
+)
+
+func initGasWatch() {
+	if os.Getenv("MINTER_ADDRESS") == "" {
+		return
+	}
+	if !common.IsHexAddress(os.Getenv("MINTER_ADDRESS")) {
+		log.Fatalf("Invalid MINTER_ADDRESS %q", os.Getenv("MINTER_ADDRESS"))
+	}
+}
+
+// checkOperatorGas takes one sample. The low flag carries the alert state
+// across calls so crossings alert exactly once.
+func checkOperatorGas(minter common.Address, threshold *big.Int, low *bool) {
+	client := activeEthClient()
+	balance, err := client.BalanceAt(rootCtx, minter, nil)
+	if err != nil {
+		logDeduped("Failed to read minter gas balance", err)
+		return
+	}
+	clearLogDedupe("Failed to read minter gas balance")
+
+	balanceFloat, _ := new(big.Float).SetInt(balance).Float64()
+	operatorGasWei.Set(balanceFloat)
+	if _, err := db.Exec(q(`INSERT INTO operator_gas (checked_at, balance) VALUES (?, ?)
+		ON CONFLICT(checked_at) DO UPDATE SET balance = excluded.balance`),
+		time.Now().Unix(), balance.String()); err != nil {
+		log.Printf("Failed to record minter gas balance: %v", err)
+	}
+
+	if threshold.Sign() > 0 && balance.Cmp(threshold) < 0 {
+		if !*low {
+			*low = true
+			detail := "Minter " + minter.Hex() + " gas balance " + balance.String() +
+				" wei is below threshold " + threshold.String() + " wei; mints will halt when it reaches zero"
+			log.Printf("ALERT: %s", detail)
+			dispatchNotification("operator_gas", notifyToken(), detail, balance)
+		}
+		return
+	}
+	if *low {
+		*low = false
+		log.Printf("Minter %s gas balance recovered to %s wei", minter.Hex(), balance.String())
+	}
+}
+
+// runGasWatch is the sampling loop, started once from main.
+func runGasWatch() {
+	raw := os.Getenv("MINTER_ADDRESS")
+	if raw == "" {
+		return
+	}
+	minter := common.HexToAddress(raw)
+	threshold := new(big.Int)
+	if rawThreshold := os.Getenv("GAS_ALERT_THRESHOLD"); rawThreshold != "" {
+		if _, ok := threshold.SetString(rawThreshold, 10); !ok {
+			log.Fatalf("Invalid GAS_ALERT_THRESHOLD %q: want wei as a decimal integer", rawThreshold)
+		}
+	}
+	interval := envDuration("GAS_WATCH_INTERVAL", 5*time.Minute)
+	log.Printf("Watching minter %s gas balance every %s (alert below %s wei)", minter.Hex(), interval, threshold)
+	low := false
+	for {
+		checkOperatorGas(minter, threshold, &low)
+		sleepOrShutdown(interval)
+		if stopRequested.Load() {
+			return
+		}
+	}
+}
+
+// handleOperatorGas serves GET /operator/gas?limit=: the recorded balance
+// series, newest first.
+func handleOperatorGas(w http.ResponseWriter, r *http.Request) {
+	if os.Getenv("MINTER_ADDRESS") == "" {
+		http.Error(w, "gas watching disabled: MINTER_ADDRESS not configured", http.StatusNotFound)
+		return
+	}
+	limit := envQueryUint(r, "limit", 288)
+	if limit > 10000 {
+		limit = 10000
+	}
+	rows, err := readDB.Query(q(`SELECT checked_at, balance FROM operator_gas ORDER BY checked_at DESC LIMIT ?`), limit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	type sample struct {
+		CheckedAt int64  `json:"checked_at"`
+		Balance   string `json:"balance"`
+	}
+	samples := []sample{}
+	for rows.Next() {
+		var item sample
+		if err := rows.Scan(&item.CheckedAt, &item.Balance); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		samples = append(samples, item)
+	}
+	if err := rows.Err(); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, map[string]interface{}{
+		"minter":  common.HexToAddress(os.Getenv("MINTER_ADDRESS")).Hex(),
+		"samples": samples,
+	})
+}
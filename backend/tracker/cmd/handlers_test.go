@@ -0,0 +1,146 @@
+package main
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func initTestABI(t *testing.T) {
+	t.Helper()
+	if len(contractABI.Events) > 0 {
+		return
+	}
+	parsed, err := abi.JSON(strings.NewReader(wbtcABI))
+	if err != nil {
+		t.Fatalf("parse ABI: %v", err)
+	}
+	contractABI = parsed
+}
+
+func holderCount(t *testing.T) int {
+	t.Helper()
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM wbtc_holders").Scan(&count); err != nil {
+		t.Fatalf("count holders: %v", err)
+	}
+	return count
+}
+
+func TestHandleTransferEventTruncatedTopics(t *testing.T) {
+	initTestABI(t)
+	setupTestDB(t)
+	vLog := types.Log{
+		Topics: []common.Hash{
+			contractABI.Events["Transfer"].ID,
+			common.HexToHash("0x01"), // only from; to is missing
+		},
+		TxHash: common.HexToHash("0xdead"),
+	}
+	handleTransferEvent(vLog) // must not panic
+	if n := holderCount(t); n != 0 {
+		t.Errorf("truncated Transfer log mutated %d balances", n)
+	}
+}
+
+func TestHandleTransferEventSelfTransfer(t *testing.T) {
+	initTestABI(t)
+	setupTestDB(t)
+	t.Setenv("TRACK_TRANSFERS", "true")
+	initLedger()
+	defer func() { ledgerEnabled = false }()
+
+	const addr = "0x0000000000000000000000000000000000005e1f"
+	checksummed := common.HexToAddress(addr).Hex()
+	updateBalance(checksummed, big.NewInt(50), 1)
+
+	handleTransferEvent(makeTransferLog(t, addr, addr, big.NewInt(20), 2, "0x5e1f", 0))
+
+	if got := getBalance(t, checksummed); got != 50 {
+		t.Errorf("balance after self-transfer = %d, want unchanged 50", got)
+	}
+	var rows int
+	if err := db.QueryRow("SELECT COUNT(*) FROM transfers").Scan(&rows); err != nil {
+		t.Fatalf("count ledger rows: %v", err)
+	}
+	if rows != 1 {
+		t.Errorf("ledger rows after self-transfer = %d, want exactly 1", rows)
+	}
+}
+
+func TestHandleTransferEventBeyondInt64(t *testing.T) {
+	initTestABI(t)
+	setupTestDB(t)
+	const (
+		alice = "0x0000000000000000000000000000000000000a11"
+		bob   = "0x0000000000000000000000000000000000000b0b"
+	)
+	// 2^200 tokens: nonsense economically, but a valid uint256 the handler
+	// must carry through without truncation.
+	huge := new(big.Int).Lsh(big.NewInt(1), 200)
+	handleTransferEvent(makeTransferLog(t, zeroAddress, alice, huge, 1, "0x01", 0))
+	handleTransferEvent(makeTransferLog(t, alice, bob, big.NewInt(5), 2, "0x02", 0))
+
+	wantAlice := new(big.Int).Sub(huge, big.NewInt(5)).String()
+	if got := getBalanceText(t, common.HexToAddress(alice).Hex()); got != wantAlice {
+		t.Errorf("alice balance = %s, want %s", got, wantAlice)
+	}
+	if got := getBalanceText(t, common.HexToAddress(bob).Hex()); got != "5" {
+		t.Errorf("bob balance = %s, want 5", got)
+	}
+}
+
+func TestDecodeEventIndexedValue(t *testing.T) {
+	initTestABI(t)
+	// A non-standard Transfer that indexes value puts it in Topics[3] and
+	// leaves data empty; decodeEvent must pull it from the topic instead of
+	// reporting a partial unpack.
+	const indexedValueABI = `[{"anonymous":false,"inputs":[
+		{"indexed":true,"name":"from","type":"address"},
+		{"indexed":true,"name":"to","type":"address"},
+		{"indexed":true,"name":"value","type":"uint256"}],
+		"name":"Transfer","type":"event"}]`
+	saved := contractABI
+	parsed, err := abi.JSON(strings.NewReader(indexedValueABI))
+	if err != nil {
+		t.Fatalf("parse indexed-value ABI: %v", err)
+	}
+	contractABI = parsed
+	defer func() { contractABI = saved }()
+
+	vLog := types.Log{
+		Topics: []common.Hash{
+			contractABI.Events["Transfer"].ID,
+			common.HexToHash("0x01"),
+			common.HexToHash("0x02"),
+			common.BigToHash(big.NewInt(777)),
+		},
+		TxHash: common.HexToHash("0xcafe"),
+	}
+	var transferEvent struct {
+		Value *big.Int
+	}
+	if err := decodeEvent("Transfer", &transferEvent, vLog); err != nil {
+		t.Fatalf("decodeEvent with indexed value: %v", err)
+	}
+	if transferEvent.Value == nil || transferEvent.Value.Int64() != 777 {
+		t.Errorf("decoded value = %v, want 777", transferEvent.Value)
+	}
+}
+
+func TestHandleTokensBurnedEventTruncatedTopics(t *testing.T) {
+	initTestABI(t)
+	setupTestDB(t)
+	vLog := types.Log{
+		Topics: []common.Hash{contractABI.Events["TokensBurned"].ID}, // from is missing
+		TxHash: common.HexToHash("0xbeef"),
+	}
+	handleTokensBurnedEvent(vLog) // must not panic
+	if n := holderCount(t); n != 0 {
+		t.Errorf("truncated TokensBurned log mutated %d balances", n)
+	}
+}
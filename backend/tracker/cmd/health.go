@@ -0,0 +1,169 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Shared view of the indexing loop's position, updated by updateHolders and
+// read by the HTTP handlers.
+var trackerStatus struct {
+	sync.Mutex
+	lastProcessedBlock uint64
+	latestBlock        uint64
+	mode               string // "backfill" until caught up, then "live"
+}
+
+func setTrackerMode(mode string) {
+	trackerStatus.Lock()
+	trackerStatus.mode = mode
+	trackerStatus.Unlock()
+}
+
+func trackerMode() string {
+	trackerStatus.Lock()
+	defer trackerStatus.Unlock()
+	if trackerStatus.mode == "" {
+		return "backfill"
+	}
+	return trackerStatus.mode
+}
+
+func setTrackerStatus(lastProcessed, latest uint64) {
+	trackerStatus.Lock()
+	trackerStatus.lastProcessedBlock = lastProcessed
+	trackerStatus.latestBlock = latest
+	trackerStatus.Unlock()
+}
+
+type healthComponent struct {
+	Status string                 `json:"status"` // ok, degraded, down or disabled
+	Detail map[string]interface{} `json:"detail,omitempty"`
+}
+
+// healthLagDegraded is how many blocks behind head the indexer may be before
+// the eth_indexer component reports degraded instead of ok.
+const healthLagDegraded = 50
+
+func checkIndexerLag() healthComponent {
+	trackerStatus.Lock()
+	processed, latest := trackerStatus.lastProcessedBlock, trackerStatus.latestBlock
+	trackerStatus.Unlock()
+	if latest == 0 {
+		return healthComponent{Status: "down", Detail: map[string]interface{}{
+			"reason": "no latest block observed yet",
+		}}
+	}
+	lag := uint64(0)
+	if latest > processed {
+		lag = latest - processed
+	}
+	status := "ok"
+	if lag > healthLagDegraded {
+		status = "degraded"
+	}
+	return healthComponent{Status: status, Detail: map[string]interface{}{
+		"last_processed_block": processed,
+		"latest_block":         latest,
+		"lag":                  lag,
+		"mode":                 trackerMode(),
+	}}
+}
+
+// Sub-checks whose backing subsystems are optional report "disabled" rather
+// than failing the composite, so a deployment without e.g. the BTC watcher
+// still gets a meaningful overall status.
+func checkBTCWatcher() healthComponent {
+	return healthComponent{Status: "disabled"}
+}
+
+// checkUnreconciledMints and checkUnreconciledBurns count the bridge flow
+// recorded after the block of the last completed supply reconciliation —
+// rows the supply invariant has not yet vouched for. The counts are
+// informational and never degrade the composite on their own; a real
+// divergence shows up through checkSupplyDivergence.
+func checkUnreconciledMints() healthComponent {
+	return checkUnreconciledEvents("TokensMinted", "mints")
+}
+
+func checkUnreconciledBurns() healthComponent {
+	return checkUnreconciledEvents("TokensBurned", "redemptions")
+}
+
+func checkUnreconciledEvents(event, table string) healthComponent {
+	if !eventEnabled(event) {
+		return healthComponent{Status: "disabled"}
+	}
+	supplyState.Lock()
+	sinceBlock := supplyState.lastCheckBlock
+	supplyState.Unlock()
+	var count int
+	err := readDB.QueryRow(q("SELECT COUNT(*) FROM "+table+" WHERE block > ?"), sinceBlock).Scan(&count)
+	if err != nil {
+		return healthComponent{Status: "down", Detail: map[string]interface{}{
+			"error": err.Error(),
+		}}
+	}
+	return healthComponent{Status: "ok", Detail: map[string]interface{}{
+		"count":       count,
+		"since_block": sinceBlock,
+	}}
+}
+
+// checkSupplyDivergence mirrors the supply check's alert condition: a
+// single mismatch is a transient read skew and stays ok, two consecutive
+// mismatches — the same threshold that pages — degrade the composite.
+func checkSupplyDivergence() healthComponent {
+	if envDuration("SUPPLY_CHECK_INTERVAL", 10*time.Minute) <= 0 {
+		return healthComponent{Status: "disabled"}
+	}
+	supplyState.Lock()
+	defer supplyState.Unlock()
+	if supplyState.lastChecked.IsZero() {
+		return healthComponent{Status: "ok", Detail: map[string]interface{}{
+			"reason": "no reconciliation completed yet",
+		}}
+	}
+	status := "ok"
+	if supplyState.lastMisses >= 2 {
+		status = "degraded"
+	}
+	return healthComponent{Status: status, Detail: map[string]interface{}{
+		"running":    supplyState.running.String(),
+		"on_chain":   supplyState.lastOnChain.String(),
+		"delta":      supplyState.lastDiff.String(),
+		"block":      supplyState.lastCheckBlock,
+		"checked_at": supplyState.lastChecked.UTC().Format(time.RFC3339),
+	}}
+}
+
+// handleBridgeHealth serves GET /bridge/health, the single endpoint the ops
+// dashboard polls. The overall status is the worst of the enabled
+// components: any "down" wins, then any "degraded", otherwise "ok".
+func handleBridgeHealth(w http.ResponseWriter, r *http.Request) {
+	components := map[string]healthComponent{
+		"eth_indexer":        checkIndexerLag(),
+		"btc_watcher":        checkBTCWatcher(),
+		"unreconciled_mints": checkUnreconciledMints(),
+		"unreconciled_burns": checkUnreconciledBurns(),
+		"supply_divergence":  checkSupplyDivergence(),
+	}
+	overall := "ok"
+	for _, c := range components {
+		switch c.Status {
+		case "down":
+			overall = "down"
+		case "degraded":
+			if overall == "ok" {
+				overall = "degraded"
+			}
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":     overall,
+		"components": components,
+	})
+}
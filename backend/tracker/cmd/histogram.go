@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// Balance distribution histogram. Bucket boundaries are configured in
+// whole-token units (HISTOGRAM_BUCKETS, e.g. "0.01,0.1,1,10") and
+// converted to base units via the token's decimals; all comparisons happen
+// in exact big-integer arithmetic, never float.
+func histogramBucketSpec() string {
+	if spec := os.Getenv("HISTOGRAM_BUCKETS"); spec != "" {
+		return spec
+	}
+	return "0.01,0.1,1,10"
+}
+
+// parseBucketBounds converts whole-token boundaries into ascending base-unit
+// big integers. Boundaries that don't land on a whole base unit are
+// rejected rather than rounded.
+func parseBucketBounds(spec string, decimals uint64) ([]*big.Int, error) {
+	scale := new(big.Int).Exp(big.NewInt(10), new(big.Int).SetUint64(decimals), nil)
+	var bounds []*big.Int
+	for _, s := range strings.Split(spec, ",") {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		r, ok := new(big.Rat).SetString(s)
+		if !ok {
+			return nil, fmt.Errorf("invalid bucket boundary %q", s)
+		}
+		r.Mul(r, new(big.Rat).SetInt(scale))
+		if !r.IsInt() {
+			return nil, fmt.Errorf("bucket boundary %q is finer than %d decimals", s, decimals)
+		}
+		bound := new(big.Int).Set(r.Num())
+		if len(bounds) > 0 && bound.Cmp(bounds[len(bounds)-1]) <= 0 {
+			return nil, fmt.Errorf("bucket boundaries must be strictly ascending")
+		}
+		bounds = append(bounds, bound)
+	}
+	if len(bounds) == 0 {
+		return nil, fmt.Errorf("no bucket boundaries configured")
+	}
+	return bounds, nil
+}
+
+// bucketIndex returns which bucket a balance falls in: bucket i holds
+// balances below bounds[i], with one final bucket for everything at or
+// above the last boundary.
+func bucketIndex(balance *big.Int, bounds []*big.Int) int {
+	for i, bound := range bounds {
+		if balance.Cmp(bound) < 0 {
+			return i
+		}
+	}
+	return len(bounds)
+}
+
+// handleStatsHistogram serves GET /stats/histogram, streaming through the
+// holders table so memory stays bounded regardless of holder count.
+func handleStatsHistogram(w http.ResponseWriter, r *http.Request) {
+	bounds, err := parseBucketBounds(histogramBucketSpec(), tokenDecimals())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	rows, err := readDB.Query("SELECT balance FROM wbtc_holders WHERE " + balanceExpr() + " > 0")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	counts := make([]int64, len(bounds)+1)
+	balance := new(big.Int)
+	for rows.Next() {
+		var raw string
+		if err := rows.Scan(&raw); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if _, ok := balance.SetString(raw, 10); !ok {
+			http.Error(w, fmt.Sprintf("corrupt balance %q", raw), http.StatusInternalServerError)
+			return
+		}
+		counts[bucketIndex(balance, bounds)]++
+	}
+	if err := rows.Err(); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	labels := strings.Split(histogramBucketSpec(), ",")
+	type bucket struct {
+		Range string `json:"range"`
+		Count int64  `json:"count"`
+	}
+	buckets := make([]bucket, 0, len(counts))
+	for i, count := range counts {
+		var label string
+		switch {
+		case i == 0:
+			label = "<" + strings.TrimSpace(labels[0])
+		case i == len(bounds):
+			label = strings.TrimSpace(labels[len(labels)-1]) + "+"
+		default:
+			label = strings.TrimSpace(labels[i-1]) + "-" + strings.TrimSpace(labels[i])
+		}
+		buckets = append(buckets, bucket{Range: label, Count: count})
+	}
+	writeJSON(w, buckets)
+}
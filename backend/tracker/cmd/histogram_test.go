@@ -0,0 +1,47 @@
+package main
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestParseBucketBoundsExactUnits(t *testing.T) {
+	bounds, err := parseBucketBounds("0.01,0.1,1,10", 8)
+	if err != nil {
+		t.Fatalf("parseBucketBounds: %v", err)
+	}
+	want := []int64{1000000, 10000000, 100000000, 1000000000}
+	for i, bound := range bounds {
+		if bound.Cmp(big.NewInt(want[i])) != 0 {
+			t.Errorf("bound[%d] = %s, want %d", i, bound, want[i])
+		}
+	}
+	if _, err := parseBucketBounds("0.000000001", 8); err == nil {
+		t.Errorf("boundary finer than decimals should be rejected")
+	}
+	if _, err := parseBucketBounds("1,0.5", 8); err == nil {
+		t.Errorf("non-ascending boundaries should be rejected")
+	}
+}
+
+func TestBucketIndex(t *testing.T) {
+	bounds, err := parseBucketBounds("1,10", 8)
+	if err != nil {
+		t.Fatalf("parseBucketBounds: %v", err)
+	}
+	tests := []struct {
+		balance int64
+		want    int
+	}{
+		{0, 0},
+		{99999999, 0},
+		{100000000, 1},
+		{999999999, 1},
+		{1000000000, 2},
+	}
+	for _, tt := range tests {
+		if got := bucketIndex(big.NewInt(tt.balance), bounds); got != tt.want {
+			t.Errorf("bucketIndex(%d) = %d, want %d", tt.balance, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"math"
+	"math/big"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Per-address balance history for charting. Recording is opt-in via
+// TRACK_HISTORY since it adds a write per balance mutation. Old rows are
+// downsampled in the background to keep the table bounded.
+var (
+	historyEnabled    bool
+	historyRetention  uint64 // keep full resolution for this many recent blocks
+	historyResolution uint64 // one sample per this many blocks once compacted
+)
+
+func envUint(name string, def uint64) uint64 {
+	v := os.Getenv(name)
+	if v == "" {
+		return def
+	}
+	n, err := strconv.ParseUint(v, 10, 64)
+	if err != nil {
+		log.Fatalf("Invalid %s %q: %v", name, v, err)
+	}
+	return n
+}
+
+func initHistory() {
+	historyEnabled = os.Getenv("TRACK_HISTORY") == "true"
+	historyRetention = envUint("HISTORY_RETENTION", 50000)
+	historyResolution = envUint("HISTORY_RESOLUTION", 1000)
+	if !historyEnabled {
+		return
+	}
+	go compactHistoryLoop()
+}
+
+func recordBalanceHistory(address string, block uint64, balance *big.Int) {
+	_, err := db.Exec(q(`INSERT INTO balance_history (address, block, balance) VALUES (?, ?, ?)
+		ON CONFLICT(address, block) DO UPDATE SET balance = excluded.balance`),
+		address, block, balance.String())
+	if err != nil {
+		log.Printf("Failed to record balance history for %s: %v", address, err)
+	}
+}
+
+// compactHistory downsamples rows older than the retention horizon to one
+// sample per historyResolution blocks, always keeping each address's latest
+// row so a chart never loses its most recent point. The whole pass is a
+// single DELETE, so an interrupted run leaves the table consistent and the
+// next run picks up where it left off.
+func compactHistory(currentBlock uint64) {
+	if currentBlock <= historyRetention {
+		return
+	}
+	cutoff := currentBlock - historyRetention
+	res, err := db.Exec(q(`DELETE FROM balance_history
+		WHERE block < ?
+		  AND block % ? != 0
+		  AND (address, block) NOT IN (
+			SELECT address, MAX(block) FROM balance_history GROUP BY address
+		  )`), cutoff, historyResolution)
+	if err != nil {
+		log.Printf("Balance history compaction failed: %v", err)
+		return
+	}
+	if n, err := res.RowsAffected(); err == nil && n > 0 {
+		log.Printf("Compacted balance history: removed %d rows below block %d", n, cutoff)
+	}
+}
+
+func compactHistoryLoop() {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+	for range ticker.C {
+		compactHistory(loadCheckpoint())
+	}
+}
+
+// handleHolderHistory serves GET /holders/{address}/history?from=&to=,
+// returning the recorded (block, balance) series in ascending block order.
+// Compacted and full-resolution ranges come back in the same shape; the
+// series is just sparser before the retention horizon.
+func handleHolderHistory(w http.ResponseWriter, r *http.Request, address string) {
+	if !historyEnabled {
+		http.Error(w, "balance history is not enabled", http.StatusNotFound)
+		return
+	}
+	from := envQueryUint(r, "from", 0)
+	to := envQueryUint(r, "to", maxBlockParam)
+	rows, err := readDB.Query(q(`SELECT block, balance FROM balance_history
+		WHERE address = ? AND block >= ? AND block <= ? ORDER BY block ASC`),
+		address, from, to)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	type point struct {
+		Block   uint64 `json:"block"`
+		Balance string `json:"balance"`
+	}
+	points := []point{}
+	for rows.Next() {
+		var p point
+		if err := rows.Scan(&p.Block, &p.Balance); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		points = append(points, p)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(points)
+}
+
+// maxBlockParam is the "no upper bound" default for block-range
+// parameters. database/sql refuses to bind uint64 values with the high
+// bit set on both drivers, so the sentinel is the largest bindable value;
+// no real block number comes anywhere near it.
+const maxBlockParam = uint64(math.MaxInt64)
+
+func envQueryUint(r *http.Request, name string, def uint64) uint64 {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return def
+	}
+	n, err := strconv.ParseUint(v, 10, 64)
+	if err != nil {
+		return def
+	}
+	return n
+}
+
+// holdersRouter dispatches /holders/{address}/... paths registered on the
+// API mux.
+func holdersRouter(w http.ResponseWriter, r *http.Request) {
+	rest := strings.TrimPrefix(r.URL.Path, "/holders/")
+	parts := strings.Split(rest, "/")
+	if len(parts) == 2 && parts[1] == "history" {
+		handleHolderHistory(w, r, normalizeAddressParam(parts[0]))
+		return
+	}
+	if len(parts) == 2 && parts[1] == "transfers" {
+		handleHolderTransfers(w, r, normalizeAddressParam(parts[0]))
+		return
+	}
+	if len(parts) == 2 && (parts[1] == "recipients" || parts[1] == "senders") {
+		handleHolderFlows(w, r, normalizeAddressParam(parts[0]), parts[1])
+		return
+	}
+	if len(parts) == 2 && parts[1] == "at" {
+		handleHolderAt(w, r, normalizeAddressParam(parts[0]))
+		return
+	}
+	if len(parts) == 1 && parts[0] != "" {
+		handleHolderBalance(w, r, normalizeAddressParam(parts[0]))
+		return
+	}
+	http.NotFound(w, r)
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// The common case is a request with no query parameters at all: the unset
+// to= default is bound straight into the SQL upper-bound clause, and
+// database/sql refuses uint64 values with the high bit set, so a bad
+// sentinel turns every parameterless request into a 500.
+func TestHolderHistoryRouteWithoutQueryParams(t *testing.T) {
+	setupTestDB(t)
+	historyEnabled = true
+	defer func() { historyEnabled = false }()
+
+	addr := common.HexToAddress("0x0000000000000000000000000000000000000a11").Hex()
+	recordBalanceHistory(addr, 5, big.NewInt(100))
+	recordBalanceHistory(addr, 9, big.NewInt(250))
+
+	rec := httptest.NewRecorder()
+	holdersRouter(rec, httptest.NewRequest("GET", "/holders/"+addr+"/history", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /history without query params = %d, want 200: %s", rec.Code, rec.Body.String())
+	}
+	var points []struct {
+		Block   uint64 `json:"block"`
+		Balance string `json:"balance"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &points); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if len(points) != 2 || points[0].Block != 5 || points[1].Balance != "250" {
+		t.Errorf("points = %+v, want both recorded rows in block order", points)
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"log"
+
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// If the process dies between applying a window's balance deltas and
+// committing last_processed_block, the restart re-fetches the same window
+// and would apply every event in it a second time. processed_events records
+// each (tx hash, log index) pair as it is dispatched, and dispatchLog skips
+// pairs it has seen before, so crash-recovery replays, admin checkpoint
+// rewinds, and double-delivered streamed logs all land exactly once.
+//
+// Rows are pruned on the same horizon as the reorg waypoints: a block old
+// enough to fall out of REORG_RETENTION can only be re-seen after a reorg
+// too deep to roll back anyway. A rollback deletes its rows along with the
+// ledger's, so the canonical chain's replacement logs re-apply normally.
+
+// markEventProcessed records the pair and reports whether it was new. A
+// database error reports true: double-applying an event is visible and
+// recoverable through reconciliation, silently dropping one is neither.
+func markEventProcessed(vLog types.Log) bool {
+	res, err := db.Exec(q(`INSERT INTO processed_events (tx_hash, log_index, block) VALUES (?, ?, ?)
+		ON CONFLICT(tx_hash, log_index) DO NOTHING`),
+		vLog.TxHash.Hex(), vLog.Index, vLog.BlockNumber)
+	if err != nil {
+		log.Printf("Failed to record processed event (tx %s, index %d): %v", vLog.TxHash.Hex(), vLog.Index, err)
+		return true
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return true
+	}
+	return n > 0
+}
+
+// clearProcessedEvent forgets one pair so a deliberate re-dispatch (the
+// retry-decodes repair path) is not skipped as a duplicate.
+func clearProcessedEvent(txHash string, logIndex uint) {
+	if _, err := db.Exec(q(`DELETE FROM processed_events WHERE tx_hash = ? AND log_index = ?`),
+		txHash, logIndex); err != nil {
+		log.Printf("Failed to clear processed event (tx %s, index %d): %v", txHash, logIndex, err)
+	}
+}
+
+// pruneProcessedEvents drops pairs below the retention horizon.
+func pruneProcessedEvents(before uint64) {
+	if _, err := db.Exec(q(`DELETE FROM processed_events WHERE block < ?`), before); err != nil {
+		log.Printf("Failed to prune processed events: %v", err)
+	}
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// runInspectTx implements the inspect-tx subcommand: fetch one transaction's
+// receipt, decode every log the configured token emitted in it, and print
+// the per-address balance deltas the indexer applies for it. When the
+// transfer ledger exists, each log is also checked against what was actually
+// recorded. A focused support tool — "what did this tx do to balances?" —
+// distinct from the range scanner.
+func runInspectTx(args []string) {
+	if len(args) != 1 {
+		fmt.Fprintln(os.Stderr, "usage: inspect-tx <tx-hash>")
+		os.Exit(2)
+	}
+	txHash := common.HexToHash(args[0])
+
+	client, err := dialEthEndpoint(os.Getenv("ETHEREUM_NODE_URL"))
+	if err != nil {
+		log.Fatalf("Failed to connect to Ethereum node: %v", err)
+	}
+	receipt, err := client.TransactionReceipt(context.Background(), txHash)
+	if err != nil {
+		log.Fatalf("Failed to fetch receipt for %s: %v", txHash.Hex(), err)
+	}
+	token := common.HexToAddress(os.Getenv("WBTC_ADDRESS"))
+
+	openDatabase()
+	ledgerRecorded := map[string]bool{}
+	var ledgerQueried bool
+	rows, err := db.Query(q("SELECT log_index FROM transfers WHERE tx_hash = ?"), txHash.Hex())
+	if err == nil {
+		ledgerQueried = true
+		for rows.Next() {
+			var index uint
+			if err := rows.Scan(&index); err == nil {
+				ledgerRecorded[fmt.Sprintf("%d", index)] = true
+			}
+		}
+		rows.Close()
+	}
+
+	fmt.Printf("inspect-tx %s: block %d, status %d, %d logs\n", txHash.Hex(), receipt.BlockNumber, receipt.Status, len(receipt.Logs))
+	deltas := map[string]*big.Int{}
+	addDelta := func(address string, amount *big.Int) {
+		delta, ok := deltas[address]
+		if !ok {
+			delta = new(big.Int)
+			deltas[address] = delta
+		}
+		delta.Add(delta, amount)
+	}
+	tokenLogs := 0
+	for _, vLog := range receipt.Logs {
+		if vLog.Address != token || len(vLog.Topics) == 0 {
+			continue
+		}
+		tokenLogs++
+		name := eventName(*vLog)
+		recorded := ""
+		if ledgerQueried {
+			recorded = " ledger=missing"
+			if ledgerRecorded[fmt.Sprintf("%d", vLog.Index)] {
+				recorded = " ledger=recorded"
+			}
+		}
+		switch name {
+		case "Transfer":
+			var transferEvent struct {
+				Value *big.Int
+			}
+			if err := decodeEvent("Transfer", &transferEvent, *vLog); err != nil {
+				fmt.Printf("  log %d: Transfer, undecodable: %v\n", vLog.Index, err)
+				continue
+			}
+			from := common.HexToAddress(vLog.Topics[1].Hex()).Hex()
+			to := common.HexToAddress(vLog.Topics[2].Hex()).Hex()
+			amount := transferEvent.Value
+			kind := classifyTransfer(from, to)
+			fmt.Printf("  log %d: %s %s -> %s amount %s%s\n", vLog.Index, kind, from, to, amount, recorded)
+			if from == to {
+				continue
+			}
+			if sinkSideTracked(from) {
+				addDelta(from, new(big.Int).Neg(amount))
+			}
+			if sinkSideTracked(to) {
+				addDelta(to, amount)
+			}
+		case "TokensBurned":
+			var burnEvent struct {
+				Amount *big.Int
+				Data   []byte
+			}
+			if err := decodeEvent("TokensBurned", &burnEvent, *vLog); err != nil {
+				fmt.Printf("  log %d: TokensBurned, undecodable: %v\n", vLog.Index, err)
+				continue
+			}
+			from := common.HexToAddress(vLog.Topics[1].Hex()).Hex()
+			fmt.Printf("  log %d: TokensBurned %s amount %s btc_target=%q%s\n",
+				vLog.Index, from, burnEvent.Amount, decodeBTCTarget(burnEvent.Data), recorded)
+		default:
+			fmt.Printf("  log %d: %s (no balance effect)%s\n", vLog.Index, name, recorded)
+		}
+	}
+	if tokenLogs == 0 {
+		fmt.Printf("  no logs from token %s in this transaction\n", token.Hex())
+		return
+	}
+
+	addresses := make([]string, 0, len(deltas))
+	for address := range deltas {
+		addresses = append(addresses, address)
+	}
+	sort.Strings(addresses)
+	fmt.Println("balance deltas:")
+	for _, address := range addresses {
+		fmt.Printf("  %s %+d\n", address, deltas[address])
+	}
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Address labels. A balance listing full of bare hex addresses hides the
+// structure everyone asks about — which entry is the exchange hot wallet,
+// which is the bridge custodian. Labels live in their own table, seed from
+// ADDRESS_LABELS_FILE (JSON object or CSV, reloaded at every start so the
+// file stays authoritative for its entries), and operators can add or
+// correct individual labels at runtime through /admin/labels without
+// touching the file. Labels are presentation only; nothing in indexing
+// reads them.
+
+func initLabels() {
+	path := os.Getenv("ADDRESS_LABELS_FILE")
+	if path == "" {
+		return
+	}
+	count, err := loadLabelsFile(path)
+	if err != nil {
+		log.Fatalf("Failed to load ADDRESS_LABELS_FILE %s: %v", path, err)
+	}
+	log.Printf("Loaded %d address labels from %s", count, path)
+}
+
+// loadLabelsFile upserts every entry of a labels file. JSON files are one
+// object of address -> label; CSV files are address,label rows (a header
+// row is skipped if present). Entries added via the admin endpoint for
+// addresses the file doesn't mention are left alone.
+func loadLabelsFile(path string) (int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	labels := map[string]string{}
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		if err := json.NewDecoder(file).Decode(&labels); err != nil {
+			return 0, err
+		}
+	} else {
+		reader := csv.NewReader(file)
+		reader.FieldsPerRecord = 2
+		for {
+			record, err := reader.Read()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return 0, err
+			}
+			if !common.IsHexAddress(strings.TrimSpace(record[0])) {
+				continue // header row or junk
+			}
+			labels[record[0]] = record[1]
+		}
+	}
+
+	count := 0
+	for address, label := range labels {
+		address, label = strings.TrimSpace(address), strings.TrimSpace(label)
+		if address == "" || label == "" {
+			continue
+		}
+		if err := setLabel(common.HexToAddress(address).Hex(), label); err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, nil
+}
+
+func setLabel(address, label string) error {
+	_, err := db.Exec(q(`INSERT INTO address_labels (address, label) VALUES (?, ?)
+		ON CONFLICT(address) DO UPDATE SET label = excluded.label`), address, label)
+	return err
+}
+
+// labelFor returns the label for an address, "" when none.
+func labelFor(address string) string {
+	var label string
+	err := readDB.QueryRow(q(`SELECT label FROM address_labels WHERE address = ?`), address).Scan(&label)
+	if err == sql.ErrNoRows {
+		return ""
+	}
+	if err != nil {
+		log.Printf("Failed to read label for %s: %v", address, err)
+		return ""
+	}
+	return label
+}
+
+// loadAddressLabels reads the whole table into a map for bulk emitters,
+// mirroring loadENSNames.
+func loadAddressLabels() map[string]string {
+	rows, err := readDB.Query(`SELECT address, label FROM address_labels`)
+	if err != nil {
+		log.Printf("Failed to read address labels: %v", err)
+		return nil
+	}
+	defer rows.Close()
+	labels := map[string]string{}
+	for rows.Next() {
+		var address, label string
+		if err := rows.Scan(&address, &label); err != nil {
+			log.Printf("Failed to scan address label: %v", err)
+			return labels
+		}
+		labels[address] = label
+	}
+	return labels
+}
+
+// handleAdminLabels serves GET /admin/labels (the full map) and POST
+// /admin/labels ({"address": ..., "label": ...}; an empty label deletes) so
+// operators can label addresses as they identify them, without a restart.
+func handleAdminLabels(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(loadAddressLabels())
+	case http.MethodPost:
+		var body struct {
+			Address string `json:"address"`
+			Label   string `json:"label"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil || !common.IsHexAddress(body.Address) {
+			http.Error(w, `body must be {"address": "0x...", "label": "..."}`, http.StatusBadRequest)
+			return
+		}
+		address := common.HexToAddress(body.Address).Hex()
+		if strings.TrimSpace(body.Label) == "" {
+			if _, err := db.Exec(q(`DELETE FROM address_labels WHERE address = ?`), address); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+		} else if err := setLabel(address, strings.TrimSpace(body.Label)); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
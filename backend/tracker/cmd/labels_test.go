@@ -0,0 +1,56 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestLoadLabelsFileJSONAndCSV(t *testing.T) {
+	setupTestDB(t)
+	dir := t.TempDir()
+
+	jsonPath := filepath.Join(dir, "labels.json")
+	if err := os.WriteFile(jsonPath, []byte(`{
+		"0x00000000000000000000000000000000000000a1": "Binance hot wallet",
+		"0x00000000000000000000000000000000000000a2": "Bridge custodian"
+	}`), 0o600); err != nil {
+		t.Fatalf("write json: %v", err)
+	}
+	count, err := loadLabelsFile(jsonPath)
+	if err != nil {
+		t.Fatalf("load json labels: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("json labels loaded = %d, want 2", count)
+	}
+	if got := labelFor(common.HexToAddress("0xa1").Hex()); got != "Binance hot wallet" {
+		t.Errorf("labelFor a1 = %q", got)
+	}
+
+	csvPath := filepath.Join(dir, "labels.csv")
+	if err := os.WriteFile(csvPath, []byte("address,label\n"+
+		"0x00000000000000000000000000000000000000a1,Coinbase cold wallet\n"+
+		"0x00000000000000000000000000000000000000a3,Treasury\n"), 0o600); err != nil {
+		t.Fatalf("write csv: %v", err)
+	}
+	count, err = loadLabelsFile(csvPath)
+	if err != nil {
+		t.Fatalf("load csv labels: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("csv labels loaded = %d, want 2", count)
+	}
+	// The reload replaces the file's entry but leaves unmentioned ones alone.
+	if got := labelFor(common.HexToAddress("0xa1").Hex()); got != "Coinbase cold wallet" {
+		t.Errorf("labelFor a1 after csv reload = %q", got)
+	}
+	if got := labelFor(common.HexToAddress("0xa2").Hex()); got != "Bridge custodian" {
+		t.Errorf("labelFor a2 = %q", got)
+	}
+	if got := labelFor(common.HexToAddress("0xa3").Hex()); got != "Treasury" {
+		t.Errorf("labelFor a3 = %q", got)
+	}
+}
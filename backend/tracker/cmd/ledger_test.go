@@ -0,0 +1,45 @@
+package main
+
+import (
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// A bare GET with no from=/to=/limit= must serve the full audit trail; the
+// unset defaults are bound as SQL parameters, so they have to be values the
+// drivers accept.
+func TestHolderTransfersRouteWithoutQueryParams(t *testing.T) {
+	initTestABI(t)
+	setupTestDB(t)
+	t.Setenv("TRACK_TRANSFERS", "true")
+	initLedger()
+	defer func() { ledgerEnabled = false }()
+
+	const (
+		alice = "0x0000000000000000000000000000000000000a11"
+		bob   = "0x0000000000000000000000000000000000000b0b"
+	)
+	handleTransferEvent(makeTransferLog(t, zeroAddress, alice, big.NewInt(40), 1, "0x01", 0))
+	handleTransferEvent(makeTransferLog(t, alice, bob, big.NewInt(15), 2, "0x02", 0))
+
+	rec := httptest.NewRecorder()
+	holdersRouter(rec, httptest.NewRequest("GET", "/holders/"+alice+"/transfers", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /transfers without query params = %d, want 200: %s", rec.Code, rec.Body.String())
+	}
+	var resp struct {
+		Transfers []ledgerEntry `json:"transfers"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if len(resp.Transfers) != 2 {
+		t.Fatalf("transfers = %+v, want both ledger rows touching the address", resp.Transfers)
+	}
+	if resp.Transfers[0].Block != 2 {
+		t.Errorf("first transfer block = %d, want newest (2) first", resp.Transfers[0].Block)
+	}
+}
@@ -0,0 +1,274 @@
+package main
+
+import (
+	"bufio"
+	"encoding/binary"
+	"flag"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"log"
+	"os"
+	"strconv"
+)
+
+// export-ledger/import-ledger move the transfers ledger as a compact
+// length-prefixed binary stream, for archiving histories too large for the
+// CSV/JSON exports to be practical. Each record is the varint-encoded row;
+// a trailer carries the record count and a CRC32 of everything before it,
+// so a truncated or corrupted archive is rejected on import before any row
+// is committed. Import reuses the ledger's (tx_hash, log_index) conflict
+// clause, so re-importing an archive is idempotent.
+
+// Version 2 stores the transfer value as a length-prefixed decimal string so
+// full uint256 amounts round-trip; version 1 archives (signed varint values)
+// remain importable.
+const (
+	ledgerArchiveMagic   = "WBTL"
+	ledgerArchiveVersion = 2
+)
+
+func writeLedgerString(w io.Writer, buf []byte, s string) error {
+	n := binary.PutUvarint(buf, uint64(len(s)))
+	if _, err := w.Write(buf[:n]); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, s)
+	return err
+}
+
+// crcByteReader hashes exactly the bytes the importer consumes; hashing the
+// file directly would also cover whatever bufio read ahead, including the
+// stored checksum itself.
+type crcByteReader struct {
+	r   *bufio.Reader
+	sum hash.Hash32
+}
+
+func (c *crcByteReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.sum.Write(p[:n])
+	return n, err
+}
+
+func (c *crcByteReader) ReadByte() (byte, error) {
+	b, err := c.r.ReadByte()
+	if err == nil {
+		c.sum.Write([]byte{b})
+	}
+	return b, err
+}
+
+func readLedgerString(r *crcByteReader) (string, error) {
+	length, err := binary.ReadUvarint(r)
+	if err != nil {
+		return "", err
+	}
+	if length > 1<<20 {
+		return "", fmt.Errorf("implausible field length %d", length)
+	}
+	buf := make([]byte, length)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return "", err
+	}
+	return string(buf), nil
+}
+
+// runExportLedger implements the export-ledger subcommand: stream every
+// transfers row into a checksummed binary archive.
+func runExportLedger(args []string) {
+	fs := flag.NewFlagSet("export-ledger", flag.ExitOnError)
+	out := fs.String("out", "", "file to write the binary ledger archive to")
+	fs.Parse(args)
+	if *out == "" {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	openDatabase()
+	file, err := os.Create(*out)
+	if err != nil {
+		log.Fatalf("Failed to create %s: %v", *out, err)
+	}
+	buffered := bufio.NewWriter(file)
+	checksum := crc32.NewIEEE()
+	writer := io.MultiWriter(buffered, checksum)
+
+	if _, err := io.WriteString(writer, ledgerArchiveMagic); err != nil {
+		log.Fatalf("Failed to write archive header: %v", err)
+	}
+	if _, err := writer.Write([]byte{ledgerArchiveVersion}); err != nil {
+		log.Fatalf("Failed to write archive header: %v", err)
+	}
+
+	rows, err := readDB.Query(`SELECT tx_hash, log_index, block, event, from_address, to_address, value
+		FROM transfers ORDER BY block ASC, log_index ASC`)
+	if err != nil {
+		log.Fatalf("Failed to read transfers: %v", err)
+	}
+	defer rows.Close()
+
+	varintBuf := make([]byte, binary.MaxVarintLen64)
+	var count uint64
+	for rows.Next() {
+		var e ledgerEntry
+		if err := rows.Scan(&e.TxHash, &e.LogIndex, &e.Block, &e.Event, &e.From, &e.To, &e.Value); err != nil {
+			log.Fatalf("Failed to scan transfer row: %v", err)
+		}
+		if err := writeLedgerString(writer, varintBuf, e.TxHash); err != nil {
+			log.Fatalf("Failed to write record: %v", err)
+		}
+		n := binary.PutUvarint(varintBuf, e.LogIndex)
+		n += binary.PutUvarint(varintBuf[n:], e.Block)
+		if _, err := writer.Write(varintBuf[:n]); err != nil {
+			log.Fatalf("Failed to write record: %v", err)
+		}
+		for _, s := range []string{e.Event, e.From, e.To, e.Value} {
+			if err := writeLedgerString(writer, varintBuf, s); err != nil {
+				log.Fatalf("Failed to write record: %v", err)
+			}
+		}
+		count++
+	}
+	if err := rows.Err(); err != nil {
+		log.Fatalf("Failed to read transfers: %v", err)
+	}
+
+	// Trailer: a zero length where the next tx hash would start marks the
+	// end of records, then the count and the CRC of everything above.
+	if _, err := writer.Write([]byte{0}); err != nil {
+		log.Fatalf("Failed to write archive trailer: %v", err)
+	}
+	n := binary.PutUvarint(varintBuf, count)
+	if _, err := writer.Write(varintBuf[:n]); err != nil {
+		log.Fatalf("Failed to write archive trailer: %v", err)
+	}
+	sum := checksum.Sum32()
+	if err := binary.Write(buffered, binary.BigEndian, sum); err != nil {
+		log.Fatalf("Failed to write archive trailer: %v", err)
+	}
+	if err := buffered.Flush(); err != nil {
+		log.Fatalf("Failed to flush %s: %v", *out, err)
+	}
+	if err := file.Close(); err != nil {
+		log.Fatalf("Failed to close %s: %v", *out, err)
+	}
+	fmt.Printf("export-ledger: wrote %d transfers to %s (crc32 %08x)\n", count, *out, sum)
+}
+
+// importLedgerArchive streams an archive into the transfers table inside one
+// transaction, which only commits after the trailer checksum verifies.
+func importLedgerArchive(path string) (count uint64, err error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	buffered := bufio.NewReader(file)
+	reader := &crcByteReader{r: buffered, sum: crc32.NewIEEE()}
+
+	header := make([]byte, len(ledgerArchiveMagic)+1)
+	if _, err := io.ReadFull(reader, header); err != nil {
+		return 0, fmt.Errorf("read header: %w", err)
+	}
+	if string(header[:len(ledgerArchiveMagic)]) != ledgerArchiveMagic {
+		return 0, fmt.Errorf("not a ledger archive (bad magic)")
+	}
+	version := header[len(ledgerArchiveMagic)]
+	if version != 1 && version != ledgerArchiveVersion {
+		return 0, fmt.Errorf("unsupported archive version %d", version)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	stmt, err := tx.Prepare(q(`INSERT INTO transfers (tx_hash, log_index, block, event, from_address, to_address, value)
+		VALUES (?, ?, ?, ?, ?, ?, ?) ON CONFLICT(tx_hash, log_index) DO NOTHING`))
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+
+	for {
+		txHash, err := readLedgerString(reader)
+		if err != nil {
+			return count, fmt.Errorf("record %d: %w", count+1, err)
+		}
+		if txHash == "" {
+			break
+		}
+		logIndex, err := binary.ReadUvarint(reader)
+		if err != nil {
+			return count, fmt.Errorf("record %d: %w", count+1, err)
+		}
+		block, err := binary.ReadUvarint(reader)
+		if err != nil {
+			return count, fmt.Errorf("record %d: %w", count+1, err)
+		}
+		var event, from, to string
+		for _, dst := range []*string{&event, &from, &to} {
+			if *dst, err = readLedgerString(reader); err != nil {
+				return count, fmt.Errorf("record %d: %w", count+1, err)
+			}
+		}
+		var value string
+		if version == 1 {
+			raw, err := binary.ReadVarint(reader)
+			if err != nil {
+				return count, fmt.Errorf("record %d: %w", count+1, err)
+			}
+			value = strconv.FormatInt(raw, 10)
+		} else {
+			if value, err = readLedgerString(reader); err != nil {
+				return count, fmt.Errorf("record %d: %w", count+1, err)
+			}
+		}
+		if _, err := stmt.Exec(txHash, logIndex, block, event, from, to, value); err != nil {
+			return count, fmt.Errorf("record %d: %w", count+1, err)
+		}
+		count++
+	}
+
+	declared, err := binary.ReadUvarint(reader)
+	if err != nil {
+		return count, fmt.Errorf("read trailer: %w", err)
+	}
+	if declared != count {
+		return count, fmt.Errorf("archive declares %d records, read %d", declared, count)
+	}
+	computed := reader.sum.Sum32()
+	var stored uint32
+	if err := binary.Read(buffered, binary.BigEndian, &stored); err != nil {
+		return count, fmt.Errorf("read trailer: %w", err)
+	}
+	if stored != computed {
+		return count, fmt.Errorf("checksum mismatch: archive %08x, computed %08x", stored, computed)
+	}
+	return count, tx.Commit()
+}
+
+// runImportLedger implements the import-ledger subcommand.
+func runImportLedger(args []string) {
+	fs := flag.NewFlagSet("import-ledger", flag.ExitOnError)
+	in := fs.String("in", "", "binary ledger archive written by export-ledger")
+	fs.Parse(args)
+	if *in == "" {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	openDatabase()
+	initLedger()
+	if !ledgerEnabled {
+		log.Fatalf("import-ledger requires TRACK_TRANSFERS=true so the transfers table exists")
+	}
+	count, err := importLedgerArchive(*in)
+	if err != nil {
+		log.Fatalf("Import failed after %d records: %v", count, err)
+	}
+	fmt.Printf("import-ledger: applied %d transfers from %s\n", count, *in)
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+// During a node outage the loop retries every few seconds and would log the
+// same failure once per retry, burying everything else. logEveryN collapses
+// repeats of the same error key into one line per summary window, carrying
+// the repeat count and the latest error text so the problem and its rate
+// stay visible without the flood.
+const logDedupeWindow = time.Minute
+
+type dedupedError struct {
+	count     int
+	lastError string
+	lastLog   time.Time
+}
+
+var logDedupe struct {
+	sync.Mutex
+	byKey map[string]*dedupedError
+}
+
+// logDeduped logs the first occurrence of key immediately, then at most one
+// summary line per logDedupeWindow while the error keeps repeating.
+func logDeduped(key string, err error) {
+	logDedupe.Lock()
+	if logDedupe.byKey == nil {
+		logDedupe.byKey = map[string]*dedupedError{}
+	}
+	entry, seen := logDedupe.byKey[key]
+	if !seen {
+		entry = &dedupedError{}
+		logDedupe.byKey[key] = entry
+	}
+	entry.count++
+	entry.lastError = err.Error()
+	count := entry.count
+	due := !seen || time.Since(entry.lastLog) >= logDedupeWindow
+	if due {
+		entry.lastLog = time.Now()
+		entry.count = 0
+	}
+	logDedupe.Unlock()
+
+	if !due {
+		return
+	}
+	if count == 1 {
+		log.Printf("%s: %v", key, err)
+		return
+	}
+	log.Printf("%s: failed %d times in last %s, last error: %v", key, count, logDedupeWindow, err)
+}
+
+// clearLogDedupe resets a key once its operation succeeds again, so the next
+// failure is logged immediately instead of waiting out the window.
+func clearLogDedupe(key string) {
+	logDedupe.Lock()
+	delete(logDedupe.byKey, key)
+	logDedupe.Unlock()
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestComputeTargetBlock(t *testing.T) {
+	tests := []struct {
+		latest, confirmations, want uint64
+	}{
+		{100, 0, 100},
+		{100, 6, 94},
+		{5, 6, 0},
+		{6, 6, 0},
+	}
+	for _, tt := range tests {
+		if got := computeTargetBlock(tt.latest, tt.confirmations); got != tt.want {
+			t.Errorf("computeTargetBlock(%d, %d) = %d, want %d", tt.latest, tt.confirmations, got, tt.want)
+		}
+	}
+}
+
+func TestRefreshLatestBlockFailureThenRecover(t *testing.T) {
+	calls := 0
+	fetch := func() (uint64, error) {
+		calls++
+		switch calls {
+		case 1:
+			return 100, nil
+		case 2:
+			return 0, errors.New("provider timeout")
+		default:
+			return 105, nil
+		}
+	}
+
+	latest := refreshLatestBlock(fetch, 0)
+	if latest != 100 {
+		t.Fatalf("first fetch: latest = %d, want 100", latest)
+	}
+	latest = refreshLatestBlock(fetch, latest)
+	if latest != 100 {
+		t.Fatalf("failed fetch should keep last known good: latest = %d, want 100", latest)
+	}
+	latest = refreshLatestBlock(fetch, latest)
+	if latest != 105 {
+		t.Fatalf("recovered fetch: latest = %d, want 105", latest)
+	}
+}
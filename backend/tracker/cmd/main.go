@@ -0,0 +1,823 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"math/big"
+	"net/http"
+	_ "net/http/pprof"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	_ "github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// ABI fragment for the events the tracker indexes. TokensBurned is emitted by
+// the bridge when WBTC is burned for a BTC redemption; its data payload
+// carries the destination bitcoin address.
+const wbtcABI = `[
+	{"anonymous":false,"inputs":[{"indexed":true,"internalType":"address","name":"from","type":"address"},{"indexed":true,"internalType":"address","name":"to","type":"address"},{"indexed":false,"internalType":"uint256","name":"value","type":"uint256"}],"name":"Transfer","type":"event"},
+	{"anonymous":false,"inputs":[{"indexed":true,"internalType":"address","name":"owner","type":"address"},{"indexed":true,"internalType":"address","name":"spender","type":"address"},{"indexed":false,"internalType":"uint256","name":"value","type":"uint256"}],"name":"Approval","type":"event"},
+	{"anonymous":false,"inputs":[{"indexed":true,"internalType":"address","name":"from","type":"address"},{"indexed":false,"internalType":"uint256","name":"amount","type":"uint256"},{"indexed":false,"internalType":"bytes","name":"data","type":"bytes"}],"name":"TokensBurned","type":"event"},
+	{"anonymous":false,"inputs":[{"indexed":true,"internalType":"address","name":"to","type":"address"},{"indexed":false,"internalType":"uint256","name":"amount","type":"uint256"},{"indexed":false,"internalType":"bytes","name":"data","type":"bytes"}],"name":"TokensMinted","type":"event"},
+	{"anonymous":false,"inputs":[{"indexed":false,"internalType":"uint256","name":"epoch","type":"uint256"},{"indexed":false,"internalType":"uint256","name":"blockNumber","type":"uint256"}],"name":"EpochRolled","type":"event"},
+	{"anonymous":false,"inputs":[{"indexed":true,"internalType":"address","name":"user","type":"address"},{"indexed":false,"internalType":"uint256","name":"amount","type":"uint256"}],"name":"BridgeDeposit","type":"event"},
+	{"anonymous":false,"inputs":[{"indexed":true,"internalType":"address","name":"user","type":"address"},{"indexed":false,"internalType":"uint256","name":"amount","type":"uint256"}],"name":"BridgeWithdraw","type":"event"}
+]`
+
+var (
+	db *sql.DB
+	// readDB serves the HTTP read paths. It points at READ_DATABASE_URL (a
+	// Postgres read replica or a second read-only SQLite handle) when
+	// configured, and falls back to the primary connection otherwise. Reads
+	// through a replica may lag writes by the replication delay; the API
+	// makes no read-after-write guarantee in that configuration.
+	readDB      *sql.DB
+	dbType      string
+	contractABI abi.ABI
+	// ethClient holds the active node connection. Failover swaps it at
+	// runtime while HTTP handlers and background jobs read it from other
+	// goroutines, so it lives in an atomic pointer; readers go through
+	// currentEthClient().
+	ethClient atomic.Pointer[ethclient.Client]
+
+	decodeFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "decode_failures_total",
+		Help: "Number of logs that could not be decoded, by event name.",
+	}, []string{"event"})
+)
+
+// q rewrites ?-style placeholders to $n for postgres so the same statements
+// work against both drivers.
+func q(query string) string {
+	if dbType != "postgres" {
+		return query
+	}
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			fmt.Fprintf(&b, "$%d", n)
+		} else {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func openDatabase() {
+	dbType = os.Getenv("DB_TYPE")
+	if dbType == "" {
+		dbType = "sqlite"
+	}
+	driver := "sqlite3"
+	if dbType == "postgres" {
+		driver = "postgres"
+	}
+	url, err := resolveDatabaseURL(dbType, os.Getenv("DATABASE_URL"))
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	if dbType == "sqlite" {
+		// The URL may carry driver options after a '?'; only the file path
+		// needs its parent directory to exist.
+		path := url
+		if i := strings.IndexByte(path, '?'); i >= 0 {
+			path = path[:i]
+		}
+		if dir := filepath.Dir(path); dir != "." {
+			if err := os.MkdirAll(dir, 0o755); err != nil {
+				log.Fatalf("Failed to create database directory %s: %v", dir, err)
+			}
+		}
+		log.Printf("Using sqlite database at %s", path)
+	}
+	db, err = sql.Open(driver, url)
+	if err != nil {
+		log.Fatalf("Failed to open database: %v", err)
+	}
+	if err := runMigrations(); err != nil {
+		log.Fatalf("Failed to migrate schema: %v", err)
+	}
+	readDB = db
+	if readURL := os.Getenv("READ_DATABASE_URL"); readURL != "" {
+		readDB, err = sql.Open(driver, readURL)
+		if err != nil {
+			log.Fatalf("Failed to open read database: %v", err)
+		}
+		log.Printf("API reads use separate connection %s", readURL)
+	}
+}
+
+// resolveDatabaseURL fills in a sensible default when DATABASE_URL is
+// unset: SQLite gets a local file so a first run just works, while Postgres
+// has no usable default and fails fast with a clear message instead of a
+// confusing connection error later.
+func resolveDatabaseURL(dbType, url string) (string, error) {
+	if url != "" {
+		return url, nil
+	}
+	if dbType == "postgres" {
+		return "", fmt.Errorf("DATABASE_URL is required when DB_TYPE=postgres")
+	}
+	return "./tracker.db", nil
+}
+
+func loadCheckpoint() uint64 {
+	var value string
+	err := db.QueryRow(q("SELECT value FROM tracker_state WHERE key = ?"), "last_processed_block").Scan(&value)
+	if err == sql.ErrNoRows {
+		raw := os.Getenv("STARTING_BLOCK")
+		if raw == "" {
+			return discoverStartingBlock()
+		}
+		start, perr := strconv.ParseUint(raw, 10, 64)
+		if perr != nil {
+			log.Fatalf("Invalid STARTING_BLOCK %q: %v", raw, perr)
+		}
+		return start
+	}
+	if err != nil {
+		log.Fatalf("Failed to load checkpoint: %v", err)
+	}
+	block, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		log.Fatalf("Corrupt checkpoint value %q: %v", value, err)
+	}
+	return block
+}
+
+func saveCheckpoint(block uint64) {
+	_, err := db.Exec(q(`INSERT INTO tracker_state (key, value) VALUES (?, ?)
+		ON CONFLICT(key) DO UPDATE SET value = excluded.value`),
+		"last_processed_block", strconv.FormatUint(block, 10))
+	if err != nil {
+		log.Printf("Failed to save checkpoint: %v", err)
+		notePendingCheckpoint(block)
+	}
+}
+
+// updateBalance applies a signed delta to an address's balance. Deltas are
+// full 256-bit integers; TEXT storage holds any of them exactly, while a
+// numeric schema is limited to int64 and raises an integrity violation for
+// anything larger rather than silently truncating. On the numeric path the
+// delta still applies in a single SQL statement so concurrent or replayed
+// deltas compose correctly. This is the path the live event handlers use.
+func updateBalance(address string, delta *big.Int, block uint64) {
+	if isDenylisted(address) {
+		return
+	}
+	if isAddressFiltered(address) {
+		return
+	}
+	var balance *big.Int
+	var err error
+	if balanceTextStorage {
+		balance, err = applyBalanceDeltaText(address, delta, block)
+	} else {
+		if !delta.IsInt64() {
+			reportIntegrityViolation(fmt.Sprintf("delta %s for %s at block %d does not fit the numeric balance schema; migrate balances to TEXT storage", delta, address, block))
+			return
+		}
+		var stored int64
+		err = db.QueryRow(q(`INSERT INTO wbtc_holders (address, balance, first_seen_block, last_active_block) VALUES (?, ?, ?, ?)
+			ON CONFLICT(address) DO UPDATE SET balance = wbtc_holders.balance + excluded.balance,
+				last_active_block = excluded.last_active_block
+			RETURNING balance`), address, delta.Int64(), block, block).Scan(&stored)
+		balance = big.NewInt(stored)
+	}
+	if err != nil {
+		enqueuePendingWrite(address, delta, block)
+		return
+	}
+	if balance.Sign() < 0 {
+		reportIntegrityViolation(fmt.Sprintf("balance of %s went negative (%s) at block %d", address, balance, block))
+	}
+	if historyEnabled {
+		recordBalanceHistory(address, block, balance)
+	}
+	invalidateStatsForDelta(delta)
+	publishChange(address, new(big.Int).Sub(balance, delta), balance, block)
+	holderCache.refresh(address, balance.String())
+}
+
+// setBalance overwrites an address's balance with a recomputed absolute
+// value. Only full rebuilds/replays should use this; for incremental
+// indexing it would clobber concurrent updates. The value is bound as its
+// decimal string: TEXT columns store it verbatim and both drivers coerce it
+// into numeric columns, erroring loudly on overflow instead of truncating.
+func setBalance(address string, balance *big.Int, block uint64) {
+	stored := balance.String()
+	_, err := db.Exec(q(`INSERT INTO wbtc_holders (address, balance, first_seen_block, last_active_block) VALUES (?, ?, ?, ?)
+		ON CONFLICT(address) DO UPDATE SET balance = ?, last_active_block = ?`),
+		address, stored, block, block, stored, block)
+	if err != nil {
+		log.Printf("Failed to set balance for %s: %v", address, err)
+		return
+	}
+	if historyEnabled {
+		recordBalanceHistory(address, block, balance)
+	}
+	holderCache.refresh(address, stored)
+}
+
+// decodeEvent unpacks the non-indexed data for the named event after checking
+// that the log's topic count matches the ABI's indexed parameters. Tokens
+// that declare Transfer with a different indexing layout would otherwise have
+// their topics mis-assigned to the wrong addresses; those logs are rejected
+// here so callers can skip them.
+func decodeEvent(name string, out interface{}, vLog types.Log) error {
+	// Contracts behind upgradeable proxies can change layouts mid-history;
+	// decode with the ABI that was live at the log's block.
+	blockABI := abiForBlock(vLog.BlockNumber)
+	event, ok := blockABI.Events[name]
+	if !ok {
+		return fmt.Errorf("event %s not in ABI", name)
+	}
+	indexed := 0
+	for _, input := range event.Inputs {
+		if input.Indexed {
+			indexed++
+		}
+	}
+	if len(vLog.Topics) != indexed+1 {
+		return fmt.Errorf("topic count %d does not match %d indexed parameters", len(vLog.Topics), indexed)
+	}
+	if err := blockABI.UnpackIntoInterface(out, name, vLog.Data); err != nil {
+		warnDecodeMismatch(name, err.Error())
+		return fmt.Errorf("unpack %s: %w", name, err)
+	}
+	// UnpackIntoInterface only reads vLog.Data, so a field the ABI declares
+	// as indexed stays nil here. Some non-standard tokens index value; pull
+	// those fields out of the matching topic before concluding the layout
+	// differs. Anything still unset after that is a genuine partial decode.
+	if missing := nilDecodedFields(out); len(missing) > 0 {
+		if err := fillFromTopics(event, out, vLog, missing); err != nil {
+			warnDecodeMismatch(name, err.Error())
+			return fmt.Errorf("unpack %s: %w", name, err)
+		}
+	}
+	if missing := nilDecodedFields(out); len(missing) > 0 {
+		detail := fmt.Sprintf("unpack left %s unset, data layout likely differs", strings.Join(missing, ", "))
+		warnDecodeMismatch(name, detail)
+		return fmt.Errorf("unpack %s: %s", name, detail)
+	}
+	return nil
+}
+
+// fillFromTopics decodes indexed event parameters into the out struct fields
+// named in missing. Topics[1:] align with the ABI's indexed inputs in
+// declaration order; only parameters the caller's struct actually wants are
+// parsed, so the standard from/to address topics stay untouched.
+func fillFromTopics(event abi.Event, out interface{}, vLog types.Log, missing []string) error {
+	wanted := make(map[string]bool, len(missing))
+	for _, name := range missing {
+		wanted[name] = true
+	}
+	var args abi.Arguments
+	var topics []common.Hash
+	topicIndex := 1
+	for _, input := range event.Inputs {
+		if !input.Indexed {
+			continue
+		}
+		if topicIndex >= len(vLog.Topics) {
+			return fmt.Errorf("missing topic for indexed parameter %s", input.Name)
+		}
+		if wanted[abi.ToCamelCase(input.Name)] {
+			args = append(args, input)
+			topics = append(topics, vLog.Topics[topicIndex])
+		}
+		topicIndex++
+	}
+	if len(args) == 0 {
+		return nil
+	}
+	return abi.ParseTopics(out, args, topics)
+}
+
+func handleTransferEvent(vLog types.Log) {
+	// Never index Topics[1]/Topics[2] without checking: a malformed log
+	// with fewer topics would panic and take down the indexer.
+	if len(vLog.Topics) < 3 {
+		recordDecodeFailure("Transfer", vLog)
+		log.Printf("Skipping Transfer log with %d topics (tx %s, index %d)", len(vLog.Topics), vLog.TxHash.Hex(), vLog.Index)
+		return
+	}
+	var transferEvent struct {
+		Value *big.Int
+	}
+	if err := decodeEvent("Transfer", &transferEvent, vLog); err != nil {
+		recordDecodeFailure("Transfer", vLog)
+		log.Printf("Skipping undecodable Transfer log (tx %s, index %d): %v", vLog.TxHash.Hex(), vLog.Index, err)
+		return
+	}
+	from := common.HexToAddress(vLog.Topics[1].Hex())
+	to := common.HexToAddress(vLog.Topics[2].Hex())
+	amount := transferEvent.Value
+	if filteredTransfer(from.Hex(), to.Hex(), amount) {
+		return
+	}
+	kind := classifyTransfer(from.Hex(), to.Hex())
+	if from == to {
+		// A self-transfer is economically a no-op; debiting and crediting
+		// the same row in two statements is wasteful and briefly wrong
+		// between them. Keep the ledger row so audits still see the event.
+		recordTransfer(vLog, "Transfer", from.Hex(), to.Hex(), amount)
+		noteDailyEvent(vLog.BlockNumber, []string{from.Hex()}, amount)
+		log.Printf("self-transfer: %s amount %s (block %d), balances unchanged", from.Hex(), amount, vLog.BlockNumber)
+		return
+	}
+	if sinkSideTracked(from.Hex()) {
+		updateBalance(from.Hex(), new(big.Int).Neg(amount), vLog.BlockNumber)
+	}
+	if sinkSideTracked(to.Hex()) {
+		updateBalance(to.Hex(), amount, vLog.BlockNumber)
+	}
+	recordTransfer(vLog, "Transfer", from.Hex(), to.Hex(), amount)
+	noteSupplyChange(from.Hex(), to.Hex(), amount, vLog.BlockNumber)
+	noteDailyEvent(vLog.BlockNumber, []string{from.Hex(), to.Hex()}, amount)
+	noteRateEvent(kind, amount)
+	dispatchNotification("large_transfer", notifyToken(),
+		fmt.Sprintf("%s -> %s amount %s (tx %s)", from.Hex(), to.Hex(), amount, vLog.TxHash.Hex()), amount)
+	log.Printf("%s: %s -> %s amount %s (block %d)", kind, from.Hex(), to.Hex(), amount, vLog.BlockNumber)
+}
+
+func handleTokensBurnedEvent(vLog types.Log) {
+	if len(vLog.Topics) < 2 {
+		recordDecodeFailure("TokensBurned", vLog)
+		log.Printf("Skipping TokensBurned log with %d topics (tx %s, index %d)", len(vLog.Topics), vLog.TxHash.Hex(), vLog.Index)
+		return
+	}
+	var burnEvent struct {
+		Amount *big.Int
+		Data   []byte
+	}
+	if err := decodeEvent("TokensBurned", &burnEvent, vLog); err != nil {
+		recordDecodeFailure("TokensBurned", vLog)
+		log.Printf("Skipping undecodable TokensBurned log (tx %s, index %d): %v", vLog.TxHash.Hex(), vLog.Index, err)
+		return
+	}
+	from := common.HexToAddress(vLog.Topics[1].Hex())
+	recordTransfer(vLog, "TokensBurned", from.Hex(), "", burnEvent.Amount)
+	recordRedemption(vLog, from.Hex(), burnEvent.Amount, burnEvent.Data)
+	noteDailyEvent(vLog.BlockNumber, []string{from.Hex()}, burnEvent.Amount)
+	noteRateEvent("burn", burnEvent.Amount)
+	log.Printf("TokensBurned: %s amount %s (block %d)", from.Hex(), burnEvent.Amount, vLog.BlockNumber)
+}
+
+func handleTokensMintedEvent(vLog types.Log) {
+	if len(vLog.Topics) < 2 {
+		recordDecodeFailure("TokensMinted", vLog)
+		log.Printf("Skipping TokensMinted log with %d topics (tx %s, index %d)", len(vLog.Topics), vLog.TxHash.Hex(), vLog.Index)
+		return
+	}
+	var mintEvent struct {
+		Amount *big.Int
+		Data   []byte
+	}
+	if err := decodeEvent("TokensMinted", &mintEvent, vLog); err != nil {
+		recordDecodeFailure("TokensMinted", vLog)
+		log.Printf("Skipping undecodable TokensMinted log (tx %s, index %d): %v", vLog.TxHash.Hex(), vLog.Index, err)
+		return
+	}
+	to := common.HexToAddress(vLog.Topics[1].Hex())
+	recordTransfer(vLog, "TokensMinted", "", to.Hex(), mintEvent.Amount)
+	recordMint(vLog, to.Hex(), mintEvent.Amount, mintEvent.Data)
+	noteDailyEvent(vLog.BlockNumber, []string{to.Hex()}, mintEvent.Amount)
+	noteRateEvent("mint", mintEvent.Amount)
+	log.Printf("TokensMinted: %s amount %s (block %d)", to.Hex(), mintEvent.Amount, vLog.BlockNumber)
+}
+
+// logStartupSummary emits one structured line answering "is it working and
+// how far behind is it?" right after bootstrap, before the loop starts. The
+// backfill estimate assumes the loop's 100-block window and 15s interval.
+func logStartupSummary(client *ethclient.Client, token common.Address, lastProcessed uint64) {
+	latestBlock, err := client.BlockNumber(rootCtx)
+	if err != nil {
+		log.Printf("startup: token=%s db=%s last_processed_block=%d latest_block=unknown (%v)",
+			token.Hex(), dbType, lastProcessed, err)
+		return
+	}
+	var lag uint64
+	if latestBlock > lastProcessed {
+		lag = latestBlock - lastProcessed
+	}
+	iterations := (lag + 99) / 100
+	estimate := time.Duration(iterations) * 15 * time.Second
+	mode := "resuming"
+	if lag == 0 {
+		mode = "caught-up"
+	} else if lag > 1000 {
+		mode = "backfilling"
+	}
+	log.Printf("startup: token=%s db=%s mode=%s last_processed_block=%d latest_block=%d lag=%d window=100 interval=15s estimated_backfill=%s",
+		token.Hex(), dbType, mode, lastProcessed, latestBlock, lag, estimate)
+}
+
+var backfillComplete = promauto.NewGauge(prometheus.GaugeOpts{
+	Name: "tracker_backfill_complete",
+	Help: "Unix timestamp of the most recent backfill-to-live transition.",
+})
+
+// backfillRearmLag is how far behind the target the indexer must fall, in
+// blocks, before it is considered to be backfilling again. A single missed
+// poll should not flap the mode.
+const backfillRearmLag = 200
+
+// noteCatchUpProgress flips the shared mode to live the first time the loop
+// reaches the target head, emitting the transition log and metric exactly
+// once per catch-up, and re-arms when the indexer falls well behind again.
+func noteCatchUpProgress(lastProcessed, target uint64) {
+	switch {
+	case trackerMode() != "live" && lastProcessed >= target:
+		setTrackerMode("live")
+		backfillComplete.SetToCurrentTime()
+		log.Printf("transition: mode=live block=%d target=%d backfill complete", lastProcessed, target)
+	case trackerMode() == "live" && target > lastProcessed && target-lastProcessed > backfillRearmLag:
+		setTrackerMode("backfill")
+		log.Printf("transition: mode=backfill block=%d target=%d lag=%d fell behind", lastProcessed, target, target-lastProcessed)
+	}
+}
+
+// catchUpOnly makes the indexer exit once it reaches the target head
+// instead of tailing, for cron-style snapshot jobs. Set via CATCH_UP_ONLY
+// or the --once flag.
+var catchUpOnly bool
+
+// computeTargetBlock is the highest block the loop should process given the
+// observed head and the configured confirmation depth.
+func computeTargetBlock(latest, confirmations uint64) uint64 {
+	if confirmations >= latest {
+		return 0
+	}
+	return latest - confirmations
+}
+
+// exitAtHead terminates a catch-up-only run once the loop reaches the
+// target block, making sure buffered writes and the checkpoint are durably
+// committed first.
+func exitAtHead(lastProcessed, target uint64) {
+	for attempt := 0; attempt < 5; attempt++ {
+		if flushWriteBuffer() {
+			log.Printf("Catch-up complete at block %d (target %d); exiting", lastProcessed, target)
+			os.Exit(0)
+		}
+		time.Sleep(2 * time.Second)
+	}
+	log.Printf("Catch-up reached block %d but buffered writes could not be committed", lastProcessed)
+	os.Exit(1)
+}
+
+// refreshLatestBlock fetches the current head, holding on to the last known
+// good value when the fetch fails. The loop keeps draining already-observed
+// blocks against the stale head instead of re-entering with a recomputed
+// range, so a flaky provider no longer causes redundant re-scans.
+func refreshLatestBlock(fetch func() (uint64, error), lastKnown uint64) uint64 {
+	latest, err := fetch()
+	if err != nil {
+		logDeduped("Failed to fetch latest block", err)
+		noteRPCFailure()
+		return lastKnown
+	}
+	clearLogDedupe("Failed to fetch latest block")
+	return latest
+}
+
+// buildFilterQuery is the one place the tracker's log filter is
+// constructed, shared by the indexing loop and the verification tooling.
+func buildFilterQuery(contractAddress common.Address, fromBlock, toBlock uint64) ethereum.FilterQuery {
+	return ethereum.FilterQuery{
+		FromBlock: new(big.Int).SetUint64(fromBlock),
+		ToBlock:   new(big.Int).SetUint64(toBlock),
+		Addresses: []common.Address{contractAddress},
+		Topics:    [][]common.Hash{enabledTopics},
+	}
+}
+
+func updateHolders(client *ethclient.Client) {
+	contractAddress := common.HexToAddress(os.Getenv("WBTC_ADDRESS"))
+	confirmations := envUint("CONFIRMATIONS", 0)
+	if confirmations > 0 {
+		log.Printf("Indexing %d confirmations behind the head; shallow reorgs up to that depth never reach the database", confirmations)
+	}
+	// window sizes one FilterLogs call, adapting to what the provider and
+	// the contract's activity allow; maxPerIteration bounds one pass of the
+	// outer loop, so a large gap still yields regularly to the pause check,
+	// status and metrics updates instead of grinding through windows
+	// back-to-back.
+	window := newAdaptiveWindow()
+	maxPerIteration := envUint("MAX_BLOCKS_PER_ITERATION", 1000)
+	if maxPerIteration < window.size() {
+		maxPerIteration = window.size()
+	}
+	backfillWorkers := envUint("BACKFILL_WORKERS", 1)
+	if backfillWorkers == 0 {
+		backfillWorkers = 1
+	}
+
+	lastProcessedBlock := loadCheckpoint()
+	logStartupSummary(client, contractAddress, lastProcessedBlock)
+	var latestBlock uint64
+	for {
+		if stopRequested.Load() {
+			// Position is committed; let the signal handler finish the exit.
+			confirmLoopDrained()
+			time.Sleep(time.Second)
+			continue
+		}
+		if paused, reason := indexingPaused(); paused {
+			log.Printf("Indexing halted (%s); waiting for admin resume", reason)
+			sleepOrShutdown(15 * time.Second)
+			continue
+		}
+		client = activeEthClient()
+		latestBlock = refreshLatestBlock(func() (uint64, error) {
+			return client.BlockNumber(rootCtx)
+		}, latestBlock)
+		noteHead(latestBlock)
+		if latestBlock == 0 {
+			// No head observed yet; nothing can be processed.
+			sleepOrShutdown(15 * time.Second)
+			continue
+		}
+		if ancestor, ok, reorged := detectReorg(client); reorged {
+			lastProcessedBlock = handleDetectedReorg(ancestor, ok, lastProcessedBlock)
+			continue
+		}
+		setTrackerStatus(lastProcessedBlock, latestBlock)
+		updatePositionMetrics(contractAddress.Hex(), tokenSymbol(), lastProcessedBlock, latestBlock)
+		if !flushWriteBuffer() && writeBufferFull() {
+			// Database still down and the buffer can't absorb another
+			// window; hold position until it recovers.
+			sleepOrShutdown(15 * time.Second)
+			continue
+		}
+		targetBlock := computeTargetBlock(latestBlock, confirmations)
+		noteCatchUpProgress(lastProcessedBlock, targetBlock)
+		fromBlock := lastProcessedBlock + 1
+		if fromBlock > targetBlock {
+			if catchUpOnly {
+				exitAtHead(lastProcessedBlock, targetBlock)
+			}
+			// Caught up: stream over WS when configured, otherwise poll.
+			if !streamLogsAtHead(contractAddress, confirmations, &lastProcessedBlock, &latestBlock) {
+				sleepOrShutdown(15 * time.Second)
+			}
+			continue
+		}
+		iterationEnd := targetBlock
+		if iterationEnd-lastProcessedBlock > maxPerIteration {
+			iterationEnd = lastProcessedBlock + maxPerIteration
+		}
+		if backfillWorkers > 1 && iterationEnd-lastProcessedBlock > window.size() {
+			lastProcessedBlock = processRangeParallel(client, contractAddress,
+				lastProcessedBlock+1, iterationEnd, window.size(), backfillWorkers)
+			setTrackerStatus(lastProcessedBlock, latestBlock)
+			updatePositionMetrics(contractAddress.Hex(), tokenSymbol(), lastProcessedBlock, latestBlock)
+			continue
+		}
+		for lastProcessedBlock < iterationEnd {
+			fromBlock := lastProcessedBlock + 1
+			toBlock := fromBlock + window.size() - 1
+			if toBlock > iterationEnd {
+				toBlock = iterationEnd
+			}
+
+			if timestampsNeeded() {
+				prefetchTimestamps(fromBlock, toBlock)
+			}
+			logs, err := fetchWindowLogs(client, contractAddress, fromBlock, toBlock)
+			if err != nil {
+				if window.shrinkOnError(err) {
+					// Result cap, not an outage: retry the range with the
+					// smaller window right away.
+					continue
+				}
+				logDeduped("Failed to filter logs", err)
+				noteRPCFailure()
+				sleepOrShutdown(15 * time.Second)
+				break
+			}
+			clearLogDedupe("Failed to filter logs")
+			noteRPCSuccess()
+			window.observe(len(logs))
+			if err := verifyWindowReceipts(client, contractAddress, fromBlock, toBlock, logs); err != nil {
+				// Treated like an outage: nothing from this window is
+				// applied, and repeated mismatches rotate the endpoint.
+				logDeduped("Receipt verification failed", err)
+				noteRPCFailure()
+				sleepOrShutdown(15 * time.Second)
+				break
+			}
+			clearLogDedupe("Receipt verification failed")
+			for _, vLog := range logs {
+				dispatchLog(vLog)
+			}
+			lastProcessedBlock = toBlock
+			saveCheckpoint(lastProcessedBlock)
+			maybeTakeSnapshot(fromBlock-1, toBlock)
+			recordProcessedRange(client, lastProcessedBlock)
+			if stopRequested.Load() {
+				break
+			}
+		}
+		setTrackerStatus(lastProcessedBlock, latestBlock)
+		updatePositionMetrics(contractAddress.Hex(), tokenSymbol(), lastProcessedBlock, latestBlock)
+	}
+}
+
+func main() {
+	logBuildInfo()
+
+	var err error
+	contractABI, err = abi.JSON(strings.NewReader(wbtcABI))
+	if err != nil {
+		log.Fatalf("Failed to parse ABI: %v", err)
+	}
+
+	initEvents()
+	initABIOverrides()
+	initTransferFilters()
+	initRPCThrottle()
+	initReceiptVerification()
+	initShutdown()
+
+	catchUpOnly = os.Getenv("CATCH_UP_ONLY") == "true"
+	reconcileReportPath := ""
+	for i := 1; i < len(os.Args); i++ {
+		switch os.Args[i] {
+		case "--once":
+			catchUpOnly = true
+		case "--reconcile-report":
+			if i+1 >= len(os.Args) {
+				log.Fatalf("--reconcile-report requires a file path")
+			}
+			reconcileReportPath = os.Args[i+1]
+			i++
+		}
+	}
+	if reconcileReportPath != "" {
+		runReconcileReport(reconcileReportPath)
+		return
+	}
+
+	if len(os.Args) > 1 && !strings.HasPrefix(os.Args[1], "-") {
+		switch os.Args[1] {
+		case "verify-range":
+			runVerifyRange(os.Args[2:])
+			return
+		case "dump-logs":
+			runDumpLogs(os.Args[2:])
+			return
+		case "replay-logs":
+			runReplayLogs(os.Args[2:])
+			return
+		case "rebuild-balances":
+			runRebuildBalances(os.Args[2:])
+			return
+		case "compare-abis":
+			runCompareABIs(os.Args[2:])
+			return
+		case "backfill-columns":
+			runBackfillColumns(os.Args[2:])
+			return
+		case "inspect-tx":
+			runInspectTx(os.Args[2:])
+			return
+		case "retry-decodes":
+			runRetryDecodes(os.Args[2:])
+			return
+		case "export-ledger":
+			runExportLedger(os.Args[2:])
+			return
+		case "import-ledger":
+			runImportLedger(os.Args[2:])
+			return
+		case "balances-at":
+			runBalancesAt(os.Args[2:])
+			return
+		default:
+			log.Fatalf("Unknown command %q", os.Args[1])
+		}
+	}
+
+	client := dialEthClient()
+	verifyChainID(client)
+	verifyContractCode(client, common.HexToAddress(os.Getenv("WBTC_ADDRESS")))
+	initChainContext(client)
+
+	openDatabase()
+	initTokenMetadata()
+	initHistory()
+	initAPI()
+	initStatCache()
+	initDenylist()
+	initAddressFilter()
+	initLedger()
+	initWriteBuffer()
+	initDailyStats()
+	initStaleDetection()
+	initChangeFeed()
+	initSinks()
+	initHolderCache()
+	initSnapshots()
+	initReorg()
+	initSafeMode()
+	initNotifications()
+	initRates()
+	initTimestamps()
+	initSupplyCheck()
+	initMempool()
+	initLabels()
+	initEventSpecs()
+	initChains()
+	initGasWatch()
+	maybeLoadBootstrapState()
+	runDecodeSelfTest(client, common.HexToAddress(os.Getenv("WBTC_ADDRESS")))
+
+	go updateHolders(client)
+	go runSupplyChecks()
+	go runENSRefresh()
+	go runDistributionSnapshots()
+	go runMempoolWatch()
+	go runMerkleCommitments()
+	go runDustPruning()
+	go runGasWatch()
+	go runSpotCheck()
+	initExtraTokens(client)
+	startChains()
+
+	// The pprof handlers register on the default mux, which the public API
+	// never serves; they are only reachable when PPROF_ADDR is set, on
+	// their own listener.
+	if pprofAddr := os.Getenv("PPROF_ADDR"); pprofAddr != "" {
+		go func() {
+			log.Printf("pprof listening on %s", pprofAddr)
+			if err := http.ListenAndServe(pprofAddr, nil); err != nil {
+				log.Printf("pprof server failed: %v", err)
+			}
+		}()
+	}
+
+	apiAddr := os.Getenv("API_ADDR")
+	if apiAddr == "" {
+		apiAddr = ":8080"
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	mux.HandleFunc("/holders/", holdersRouter)
+	mux.HandleFunc("/bridge/health", handleBridgeHealth)
+	mux.HandleFunc("/holders/batch", handleHoldersBatch)
+	mux.HandleFunc("/stats/whales", handleStatsWhales)
+	mux.HandleFunc("/admin/denylist", handleAdminDenylist)
+	mux.HandleFunc("/admin/labels", handleAdminLabels)
+	mux.HandleFunc("/blocks/", handleBlockEvents)
+	mux.HandleFunc("/stats/daily", handleStatsDaily)
+	mux.HandleFunc("/stats/distribution", handleStatsDistribution)
+	mux.HandleFunc("/pending", handlePendingTransfers)
+	mux.HandleFunc("/merkle/latest", handleMerkleLatest)
+	mux.HandleFunc("/merkle/proof", handleMerkleProof)
+	mux.HandleFunc("/events/custom", handleCustomEvents)
+	mux.HandleFunc("/chains", handleChains)
+	mux.HandleFunc("/operator/gas", handleOperatorGas)
+	mux.HandleFunc("/spotcheck", handleSpotCheck)
+	mux.HandleFunc("/holders/export.json", handleHoldersExportJSON)
+	mux.HandleFunc("/holders/export/stable", handleHoldersStableExport)
+	mux.HandleFunc("/changes", handleChanges)
+	mux.HandleFunc("/supply", handleSupply)
+	mux.HandleFunc("/holders/snapshot", handleHoldersSnapshot)
+	mux.HandleFunc("/status", handleStatus)
+	mux.HandleFunc("/admin/resume", handleAdminResume)
+	mux.HandleFunc("/admin/checkpoint", handleAdminCheckpoint)
+	mux.HandleFunc("/admin/optimize", handleAdminOptimize)
+	mux.HandleFunc("/admin/notifications", handleAdminNotifications)
+	mux.HandleFunc("/bridge/balances/", handleBridgeBalance)
+	mux.HandleFunc("/redemptions", handleRedemptions)
+	mux.HandleFunc("/allowances", handleAllowances)
+	mux.HandleFunc("/mints", handleMints)
+	mux.HandleFunc("/stats/histogram", handleStatsHistogram)
+	mux.HandleFunc("/version", handleVersion)
+	go func() {
+		if err := http.ListenAndServe(apiAddr, mux); err != nil {
+			log.Fatalf("API server failed: %v", err)
+		}
+	}()
+
+	waitForShutdown()
+}
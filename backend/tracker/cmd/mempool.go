@@ -0,0 +1,238 @@
+package main
+
+import (
+	"log"
+	"math/big"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient/gethclient"
+)
+
+// Mempool monitoring. With MEMPOOL_WATCH=true (and ETHEREUM_WS_URL set) the
+// tracker subscribes to full pending transactions, keeps the ones addressed
+// to the token contract, and decodes transfer/burn calldata, so the bridge
+// UI can show "incoming burn detected" from /pending before the transaction
+// is mined. Everything here is advisory: pending entries never touch
+// balances or the ledger, they are deleted as soon as a mined log for the
+// same transaction dispatches, and anything the mempool dropped ages out
+// after MEMPOOL_TTL (default 15m). The subscription needs a node that
+// serves newPendingTransactions with full bodies (geth and the major hosted
+// providers do); a node that doesn't just drops the subscription and the
+// watcher retries.
+var (
+	mempoolEnabled bool
+	// mempoolSelectors maps the leading 4 bytes of calldata to the transfer
+	// kind it encodes, built from signatures at init so nobody fat-fingers a
+	// hardcoded selector.
+	mempoolSelectors map[[4]byte]string
+)
+
+func initMempool() {
+	mempoolEnabled = os.Getenv("MEMPOOL_WATCH") == "true"
+	if mempoolEnabled && os.Getenv("ETHEREUM_WS_URL") == "" {
+		log.Printf("MEMPOOL_WATCH=true needs ETHEREUM_WS_URL; mempool monitoring disabled")
+		mempoolEnabled = false
+	}
+	if !mempoolEnabled {
+		return
+	}
+	mempoolSelectors = map[[4]byte]string{}
+	for sig, kind := range map[string]string{
+		"transfer(address,uint256)":             "transfer",
+		"transferFrom(address,address,uint256)": "transfer_from",
+		"burn(uint256)":                         "burn",
+		"burn(uint256,bytes)":                   "burn",
+	} {
+		var selector [4]byte
+		copy(selector[:], crypto.Keccak256([]byte(sig)))
+		mempoolSelectors[selector] = kind
+	}
+}
+
+// decodeCalldataWord returns the i-th 32-byte argument word, nil if the
+// calldata is too short for it.
+func decodeCalldataWord(data []byte, i int) []byte {
+	start := 4 + 32*i
+	if len(data) < start+32 {
+		return nil
+	}
+	return data[start : start+32]
+}
+
+// recordPendingTransaction decodes one pending transaction's calldata and
+// persists it when it is a transfer or burn. Replacement transactions
+// (same hash re-announced) hit the primary key and are ignored.
+func recordPendingTransaction(tx *types.Transaction) {
+	data := tx.Data()
+	if len(data) < 4 {
+		return
+	}
+	var selector [4]byte
+	copy(selector[:], data)
+	kind, ok := mempoolSelectors[selector]
+	if !ok {
+		return
+	}
+	sender, err := types.Sender(types.LatestSignerForChainID(tx.ChainId()), tx)
+	if err != nil {
+		log.Printf("Cannot recover sender of pending tx %s: %v", tx.Hash().Hex(), err)
+		return
+	}
+	from := sender.Hex()
+	var to string
+	var amount *big.Int
+	switch kind {
+	case "transfer":
+		word0, word1 := decodeCalldataWord(data, 0), decodeCalldataWord(data, 1)
+		if word0 == nil || word1 == nil {
+			return
+		}
+		to = common.BytesToAddress(word0).Hex()
+		amount = new(big.Int).SetBytes(word1)
+	case "transfer_from":
+		word0, word1, word2 := decodeCalldataWord(data, 0), decodeCalldataWord(data, 1), decodeCalldataWord(data, 2)
+		if word0 == nil || word1 == nil || word2 == nil {
+			return
+		}
+		from = common.BytesToAddress(word0).Hex()
+		to = common.BytesToAddress(word1).Hex()
+		amount = new(big.Int).SetBytes(word2)
+	case "burn":
+		word0 := decodeCalldataWord(data, 0)
+		if word0 == nil {
+			return
+		}
+		amount = new(big.Int).SetBytes(word0)
+	}
+	result, err := db.Exec(q(`INSERT INTO pending_transfers (tx_hash, kind, from_address, to_address, amount, seen_at)
+		VALUES (?, ?, ?, ?, ?, ?) ON CONFLICT(tx_hash) DO NOTHING`),
+		tx.Hash().Hex(), kind, from, to, amount.String(), time.Now().Unix())
+	if err != nil {
+		log.Printf("Failed to record pending tx %s: %v", tx.Hash().Hex(), err)
+		return
+	}
+	if inserted, _ := result.RowsAffected(); inserted > 0 && kind == "burn" {
+		log.Printf("Pending burn detected: %s amount %s (tx %s)", from, amount, tx.Hash().Hex())
+		dispatchNotification("pending_burn", notifyToken(),
+			"Pending burn from "+from+" (tx "+tx.Hash().Hex()+")", amount)
+	}
+}
+
+// clearPendingTransfer removes a pending entry once a mined log for its
+// transaction dispatches; the confirmed event supersedes the preview.
+func clearPendingTransfer(txHash common.Hash) {
+	if _, err := db.Exec(q(`DELETE FROM pending_transfers WHERE tx_hash = ?`), txHash.Hex()); err != nil {
+		log.Printf("Failed to clear pending tx %s: %v", txHash.Hex(), err)
+	}
+}
+
+// prunePendingTransfers ages out entries whose transaction was dropped or
+// replaced and so will never mine under the recorded hash.
+func prunePendingTransfers(ttl time.Duration) {
+	cutoff := time.Now().Add(-ttl).Unix()
+	if _, err := db.Exec(q(`DELETE FROM pending_transfers WHERE seen_at < ?`), cutoff); err != nil {
+		log.Printf("Failed to prune pending transfers: %v", err)
+	}
+}
+
+// runMempoolWatch holds the pending-transaction subscription open,
+// re-dialing whenever it drops. It shares no state with the indexing loops
+// beyond the pending_transfers table.
+func runMempoolWatch() {
+	if !mempoolEnabled {
+		return
+	}
+	wsURL := os.Getenv("ETHEREUM_WS_URL")
+	ttl := envDuration("MEMPOOL_TTL", 15*time.Minute)
+	contractAddress := common.HexToAddress(os.Getenv("WBTC_ADDRESS"))
+	for !stopRequested.Load() {
+		rpcClient, err := dialRPC(wsURL)
+		if err != nil {
+			logDeduped("Failed to connect mempool watcher", err)
+			sleepOrShutdown(15 * time.Second)
+			continue
+		}
+		txCh := make(chan *types.Transaction, 256)
+		sub, err := gethclient.New(rpcClient).SubscribeFullPendingTransactions(rootCtx, txCh)
+		if err != nil {
+			logDeduped("Failed to subscribe to pending transactions", err)
+			rpcClient.Close()
+			sleepOrShutdown(15 * time.Second)
+			continue
+		}
+		clearLogDedupe("Failed to connect mempool watcher")
+		clearLogDedupe("Failed to subscribe to pending transactions")
+		log.Printf("Watching the mempool for pending transfers to %s", contractAddress.Hex())
+
+		pruneTicker := time.NewTicker(time.Minute)
+	watch:
+		for {
+			select {
+			case tx := <-txCh:
+				if tx.To() != nil && *tx.To() == contractAddress {
+					recordPendingTransaction(tx)
+				}
+			case err := <-sub.Err():
+				log.Printf("Pending transaction subscription dropped: %v; re-dialing", err)
+				break watch
+			case <-pruneTicker.C:
+				if stopRequested.Load() {
+					break watch
+				}
+				prunePendingTransfers(ttl)
+			}
+		}
+		pruneTicker.Stop()
+		sub.Unsubscribe()
+		rpcClient.Close()
+		sleepOrShutdown(5 * time.Second)
+	}
+}
+
+// handlePendingTransfers serves GET /pending: unmined transfers and burns
+// seen in the mempool, newest first. An empty list is the normal state —
+// entries live only between first sight and mining.
+func handlePendingTransfers(w http.ResponseWriter, r *http.Request) {
+	limit := envQueryUint(r, "limit", 100)
+	if limit > 1000 {
+		limit = 1000
+	}
+	rows, err := readDB.Query(q(`SELECT tx_hash, kind, from_address, to_address, amount, seen_at
+		FROM pending_transfers ORDER BY seen_at DESC LIMIT ?`), limit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	type pendingTransfer struct {
+		TxHash string `json:"tx_hash"`
+		Kind   string `json:"kind"`
+		From   string `json:"from"`
+		To     string `json:"to,omitempty"`
+		Amount string `json:"amount"`
+		SeenAt int64  `json:"seen_at"`
+	}
+	pending := []pendingTransfer{}
+	for rows.Next() {
+		var item pendingTransfer
+		if err := rows.Scan(&item.TxHash, &item.Kind, &item.From, &item.To, &item.Amount, &item.SeenAt); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		pending = append(pending, item)
+	}
+	if err := rows.Err(); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, map[string]interface{}{
+		"enabled": mempoolEnabled,
+		"pending": pending,
+	})
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// signedPendingTx builds a signed transaction to the given contract with the
+// given calldata, the way it would arrive from the pending subscription.
+func signedPendingTx(t *testing.T, contract common.Address, data []byte) (*types.Transaction, string) {
+	t.Helper()
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	signer := types.LatestSignerForChainID(big.NewInt(1))
+	tx := types.MustSignNewTx(key, signer, &types.LegacyTx{
+		To:       &contract,
+		Gas:      60000,
+		GasPrice: big.NewInt(1),
+		Data:     data,
+	})
+	return tx, crypto.PubkeyToAddress(key.PublicKey).Hex()
+}
+
+func calldataWord(value *big.Int) []byte {
+	word := make([]byte, 32)
+	value.FillBytes(word)
+	return word
+}
+
+func TestRecordPendingTransactionDecodesTransferAndBurn(t *testing.T) {
+	setupTestDB(t)
+	t.Setenv("MEMPOOL_WATCH", "true")
+	t.Setenv("ETHEREUM_WS_URL", "ws://unused.invalid")
+	initMempool()
+	contract := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+	recipient := common.HexToAddress("0x00000000000000000000000000000000000000bb")
+
+	transferData := crypto.Keccak256([]byte("transfer(address,uint256)"))[:4]
+	transferData = append(transferData, calldataWord(new(big.Int).SetBytes(recipient.Bytes()))...)
+	transferData = append(transferData, calldataWord(big.NewInt(1234))...)
+	transferTx, sender := signedPendingTx(t, contract, transferData)
+	recordPendingTransaction(transferTx)
+
+	var kind, from, to, amount string
+	err := db.QueryRow(q(`SELECT kind, from_address, to_address, amount FROM pending_transfers WHERE tx_hash = ?`),
+		transferTx.Hash().Hex()).Scan(&kind, &from, &to, &amount)
+	if err != nil {
+		t.Fatalf("read pending transfer: %v", err)
+	}
+	if kind != "transfer" || from != sender || to != recipient.Hex() || amount != "1234" {
+		t.Errorf("pending transfer = %s %s -> %s amount %s", kind, from, to, amount)
+	}
+
+	burnData := crypto.Keccak256([]byte("burn(uint256)"))[:4]
+	burnData = append(burnData, calldataWord(big.NewInt(500))...)
+	burnTx, burner := signedPendingTx(t, contract, burnData)
+	recordPendingTransaction(burnTx)
+
+	err = db.QueryRow(q(`SELECT kind, from_address, amount FROM pending_transfers WHERE tx_hash = ?`),
+		burnTx.Hash().Hex()).Scan(&kind, &from, &amount)
+	if err != nil {
+		t.Fatalf("read pending burn: %v", err)
+	}
+	if kind != "burn" || from != burner || amount != "500" {
+		t.Errorf("pending burn = %s from %s amount %s", kind, from, amount)
+	}
+
+	// An unknown selector (approve) must not be recorded at all.
+	approveData := crypto.Keccak256([]byte("approve(address,uint256)"))[:4]
+	approveData = append(approveData, calldataWord(new(big.Int).SetBytes(recipient.Bytes()))...)
+	approveData = append(approveData, calldataWord(big.NewInt(9))...)
+	approveTx, _ := signedPendingTx(t, contract, approveData)
+	recordPendingTransaction(approveTx)
+
+	var count int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM pending_transfers`).Scan(&count); err != nil {
+		t.Fatalf("count pending: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("pending rows = %d, want 2", count)
+	}
+
+	// Mining the transfer clears its preview entry.
+	clearPendingTransfer(transferTx.Hash())
+	if err := db.QueryRow(`SELECT COUNT(*) FROM pending_transfers`).Scan(&count); err != nil {
+		t.Fatalf("count pending: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("pending rows after clear = %d, want 1", count)
+	}
+}
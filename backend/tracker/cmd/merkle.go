@@ -0,0 +1,224 @@
+package main
+
+import (
+	"bytes"
+	"database/sql"
+	"log"
+	"math/big"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Merkle commitments. At MERKLE_INTERVAL (default 0 = off) the tracker
+// hashes the full holder set into a Merkle root and stores it with the
+// checkpoint block it was computed at, so third parties can verify a
+// balance against the tracker's state with an inclusion proof instead of
+// trusting the API. The tree is deterministic: leaves are
+// keccak256(address ‖ uint256 balance) sorted by address bytes, pairs hash
+// commutatively (smaller hash first, OpenZeppelin MerkleProof style, so a
+// proof verifies without position bits), and an odd node is promoted
+// unchanged. The tracker is read-only by design and holds no keys, so
+// publishing a root on-chain is left to operator tooling reading
+// /merkle/latest.
+
+type merkleLeaf struct {
+	address common.Address
+	balance *big.Int
+}
+
+func merkleLeafHash(leaf merkleLeaf) common.Hash {
+	balance := make([]byte, 32)
+	leaf.balance.FillBytes(balance)
+	return crypto.Keccak256Hash(leaf.address.Bytes(), balance)
+}
+
+// merkleHashPair hashes two nodes smaller-first, so verification needs only
+// the sibling list and not each level's left/right position.
+func merkleHashPair(a, b common.Hash) common.Hash {
+	if bytes.Compare(a.Bytes(), b.Bytes()) <= 0 {
+		return crypto.Keccak256Hash(a.Bytes(), b.Bytes())
+	}
+	return crypto.Keccak256Hash(b.Bytes(), a.Bytes())
+}
+
+// buildMerkleLevels returns every level of the tree, leaves first and the
+// root level last. An empty holder set has no levels.
+func buildMerkleLevels(leaves []merkleLeaf) [][]common.Hash {
+	if len(leaves) == 0 {
+		return nil
+	}
+	sort.Slice(leaves, func(i, j int) bool {
+		return leaves[i].address.Cmp(leaves[j].address) < 0
+	})
+	level := make([]common.Hash, len(leaves))
+	for i, leaf := range leaves {
+		level[i] = merkleLeafHash(leaf)
+	}
+	levels := [][]common.Hash{level}
+	for len(level) > 1 {
+		next := make([]common.Hash, 0, (len(level)+1)/2)
+		for i := 0; i < len(level); i += 2 {
+			if i+1 == len(level) {
+				next = append(next, level[i])
+				continue
+			}
+			next = append(next, merkleHashPair(level[i], level[i+1]))
+		}
+		level = next
+		levels = append(levels, level)
+	}
+	return levels
+}
+
+// merkleProofFor collects the sibling hashes from leaf index up to the
+// root. Levels where the node is the promoted odd one contribute nothing.
+func merkleProofFor(levels [][]common.Hash, index int) []string {
+	proof := []string{}
+	for _, level := range levels[:len(levels)-1] {
+		sibling := index ^ 1
+		if sibling < len(level) {
+			proof = append(proof, level[sibling].Hex())
+		}
+		index /= 2
+	}
+	return proof
+}
+
+// loadMerkleLeaves reads the current non-zero holder set. Balances come
+// back as exact decimal strings in either schema.
+func loadMerkleLeaves() ([]merkleLeaf, error) {
+	rows, err := readDB.Query("SELECT address, balance FROM wbtc_holders WHERE " + balanceExpr() + " != 0")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var leaves []merkleLeaf
+	for rows.Next() {
+		var address, stored string
+		if err := rows.Scan(&address, &stored); err != nil {
+			return nil, err
+		}
+		balance, ok := new(big.Int).SetString(stored, 10)
+		if !ok {
+			log.Printf("Merkle commitment: skipping corrupt balance %q for %s", stored, address)
+			continue
+		}
+		leaves = append(leaves, merkleLeaf{address: common.HexToAddress(address), balance: balance})
+	}
+	return leaves, rows.Err()
+}
+
+// commitMerkleRoot computes and stores one commitment at the current
+// checkpoint block. Recomputing at the same block overwrites, which is a
+// no-op for a deterministic tree.
+func commitMerkleRoot() {
+	leaves, err := loadMerkleLeaves()
+	if err != nil {
+		log.Printf("Merkle commitment: failed to load holders: %v", err)
+		return
+	}
+	if len(leaves) == 0 {
+		return
+	}
+	levels := buildMerkleLevels(leaves)
+	root := levels[len(levels)-1][0]
+	trackerStatus.Lock()
+	block := trackerStatus.lastProcessedBlock
+	trackerStatus.Unlock()
+	_, err = db.Exec(q(`INSERT INTO merkle_roots (block, root, holders, computed_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT(block) DO UPDATE SET root = excluded.root, holders = excluded.holders, computed_at = excluded.computed_at`),
+		block, root.Hex(), len(leaves), time.Now().Unix())
+	if err != nil {
+		log.Printf("Merkle commitment: failed to store root: %v", err)
+		return
+	}
+	log.Printf("Merkle commitment: root %s over %d holders at block %d", root.Hex(), len(leaves), block)
+}
+
+// runMerkleCommitments is the commitment loop, started once from main.
+func runMerkleCommitments() {
+	interval := envDuration("MERKLE_INTERVAL", 0)
+	if interval <= 0 {
+		return
+	}
+	log.Printf("Merkle commitments enabled every %s", interval)
+	for {
+		sleepOrShutdown(interval)
+		if stopRequested.Load() {
+			return
+		}
+		commitMerkleRoot()
+	}
+}
+
+// handleMerkleLatest serves GET /merkle/latest: the most recent stored
+// commitment, for operators publishing roots and verifiers fetching the
+// reference value.
+func handleMerkleLatest(w http.ResponseWriter, r *http.Request) {
+	var block, holders uint64
+	var computedAt int64
+	var root string
+	err := readDB.QueryRow(`SELECT block, root, holders, computed_at FROM merkle_roots ORDER BY block DESC LIMIT 1`).
+		Scan(&block, &root, &holders, &computedAt)
+	if err == sql.ErrNoRows {
+		http.Error(w, "no merkle commitment yet", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, map[string]interface{}{
+		"block":       block,
+		"root":        root,
+		"holders":     holders,
+		"computed_at": computedAt,
+	})
+}
+
+// handleMerkleProof serves GET /merkle/proof?address=: an inclusion proof
+// for the address's balance in a tree built over the live holder set. The
+// response carries the root the proof verifies against; it matches the
+// stored commitment whenever no balance has changed since it was taken.
+func handleMerkleProof(w http.ResponseWriter, r *http.Request) {
+	raw := r.URL.Query().Get("address")
+	if !common.IsHexAddress(raw) {
+		http.Error(w, "address query parameter required", http.StatusBadRequest)
+		return
+	}
+	address := common.HexToAddress(raw)
+	leaves, err := loadMerkleLeaves()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	levels := buildMerkleLevels(leaves)
+	if levels == nil {
+		http.Error(w, "no holders", http.StatusNotFound)
+		return
+	}
+	index := -1
+	var balance *big.Int
+	for i, leaf := range leaves {
+		if leaf.address == address {
+			index, balance = i, leaf.balance
+			break
+		}
+	}
+	if index < 0 {
+		http.Error(w, "address has no balance", http.StatusNotFound)
+		return
+	}
+	writeJSON(w, map[string]interface{}{
+		"address": address.Hex(),
+		"balance": balance.String(),
+		"index":   index,
+		"leaf":    merkleLeafHash(merkleLeaf{address: address, balance: balance}).Hex(),
+		"proof":   merkleProofFor(levels, index),
+		"root":    levels[len(levels)-1][0].Hex(),
+	})
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Wrapped-BTC variants disagree on decimals: canonical WBTC uses 8, several
+// newer wrappers use 18. Every decimal-aware conversion therefore goes
+// through tokenDecimals(), which is fetched from the contract's decimals()
+// at startup and persisted in token_metadata — never assumed to be 8.
+const metadataCallABI = `[
+	{"constant":true,"inputs":[],"name":"decimals","outputs":[{"name":"","type":"uint8"}],"stateMutability":"view","type":"function"},
+	{"constant":true,"inputs":[],"name":"symbol","outputs":[{"name":"","type":"string"}],"stateMutability":"view","type":"function"}
+]`
+
+var (
+	tokenDecimalsValue uint64
+	tokenSymbolValue   string
+)
+
+func initTokenMetadata() {
+	token := common.HexToAddress(os.Getenv("WBTC_ADDRESS")).Hex()
+	decimals, err := fetchTokenDecimals(token)
+	if err == nil {
+		tokenDecimalsValue = decimals
+		// symbol() is cosmetic (metric labels); a failure is not fatal.
+		if symbol, serr := fetchTokenSymbol(token); serr == nil {
+			tokenSymbolValue = symbol
+		} else {
+			log.Printf("symbol() call failed: %v", serr)
+		}
+		_, serr := db.Exec(q(`INSERT INTO token_metadata (token, decimals, symbol) VALUES (?, ?, ?)
+			ON CONFLICT(token) DO UPDATE SET decimals = excluded.decimals, symbol = excluded.symbol`),
+			token, decimals, tokenSymbolValue)
+		if serr != nil {
+			log.Printf("Failed to store token metadata: %v", serr)
+		}
+		log.Printf("Token %s (%s) reports %d decimals", token, tokenSymbolValue, decimals)
+		return
+	}
+	log.Printf("decimals() call failed: %v; falling back to stored metadata", err)
+
+	var stored uint64
+	var storedSymbol string
+	derr := db.QueryRow(q("SELECT decimals, symbol FROM token_metadata WHERE token = ?"), token).Scan(&stored, &storedSymbol)
+	if derr == nil {
+		tokenDecimalsValue = stored
+		tokenSymbolValue = storedSymbol
+		return
+	}
+	if derr != sql.ErrNoRows {
+		log.Fatalf("Failed to load token metadata: %v", derr)
+	}
+	log.Fatalf("Cannot determine decimals for %s: decimals() failed and no stored metadata exists", token)
+}
+
+// fetchTokenDecimals calls decimals() on the token contract.
+func fetchTokenDecimals(token string) (uint64, error) {
+	callABI, err := abi.JSON(strings.NewReader(metadataCallABI))
+	if err != nil {
+		return 0, fmt.Errorf("parse metadata ABI: %w", err)
+	}
+	data, err := callABI.Pack("decimals")
+	if err != nil {
+		return 0, fmt.Errorf("pack decimals call: %w", err)
+	}
+	addr := common.HexToAddress(token)
+	result, err := currentEthClient().CallContract(context.Background(), ethereum.CallMsg{To: &addr, Data: data}, nil)
+	if err != nil {
+		return 0, fmt.Errorf("call decimals(): %w", err)
+	}
+	out, err := callABI.Unpack("decimals", result)
+	if err != nil {
+		return 0, fmt.Errorf("unpack decimals(): %w", err)
+	}
+	decimals, ok := out[0].(uint8)
+	if !ok {
+		return 0, fmt.Errorf("decimals() returned unexpected type %T", out[0])
+	}
+	return uint64(decimals), nil
+}
+
+// fetchTokenSymbol calls symbol() on the token contract.
+func fetchTokenSymbol(token string) (string, error) {
+	callABI, err := abi.JSON(strings.NewReader(metadataCallABI))
+	if err != nil {
+		return "", fmt.Errorf("parse metadata ABI: %w", err)
+	}
+	data, err := callABI.Pack("symbol")
+	if err != nil {
+		return "", fmt.Errorf("pack symbol call: %w", err)
+	}
+	addr := common.HexToAddress(token)
+	result, err := currentEthClient().CallContract(context.Background(), ethereum.CallMsg{To: &addr, Data: data}, nil)
+	if err != nil {
+		return "", fmt.Errorf("call symbol(): %w", err)
+	}
+	out, err := callABI.Unpack("symbol", result)
+	if err != nil {
+		return "", fmt.Errorf("unpack symbol(): %w", err)
+	}
+	symbol, ok := out[0].(string)
+	if !ok {
+		return "", fmt.Errorf("symbol() returned unexpected type %T", out[0])
+	}
+	return symbol, nil
+}
+
+// tokenDecimals returns the token's decimal count resolved at startup.
+func tokenDecimals() uint64 {
+	return tokenDecimalsValue
+}
+
+// tokenSymbol returns the token's symbol resolved at startup, possibly "".
+func tokenSymbol() string {
+	return tokenSymbolValue
+}
+
+// formatUnits renders a base-unit balance in whole-token units, trimming
+// trailing zeros from the fractional part ("1.2345", "0.05", "42").
+func formatUnits(balance *big.Int, decimals uint64) string {
+	scale := new(big.Int).Exp(big.NewInt(10), new(big.Int).SetUint64(decimals), nil)
+	abs := new(big.Int).Abs(balance)
+	whole, frac := new(big.Int).QuoRem(abs, scale, new(big.Int))
+	sign := ""
+	if balance.Sign() < 0 {
+		sign = "-"
+	}
+	if frac.Sign() == 0 {
+		return sign + whole.String()
+	}
+	fracStr := strings.TrimRight(fmt.Sprintf("%0*s", int(decimals), frac.String()), "0")
+	return sign + whole.String() + "." + fracStr
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestFormatUnitsEighteenDecimals(t *testing.T) {
+	tests := []struct {
+		balance  string
+		decimals uint64
+		want     string
+	}{
+		{"1234500000000000000", 18, "1.2345"},
+		{"50000000000000000", 18, "0.05"},
+		{"1000000000000000000", 18, "1"},
+		{"100000000", 8, "1"},
+		{"123456789", 8, "1.23456789"},
+		{"0", 18, "0"},
+		{"-1500000000000000000", 18, "-1.5"},
+	}
+	for _, tt := range tests {
+		balance, ok := new(big.Int).SetString(tt.balance, 10)
+		if !ok {
+			t.Fatalf("bad balance literal %q", tt.balance)
+		}
+		if got := formatUnits(balance, tt.decimals); got != tt.want {
+			t.Errorf("formatUnits(%s, %d) = %q, want %q", tt.balance, tt.decimals, got, tt.want)
+		}
+	}
+}
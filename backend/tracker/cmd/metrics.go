@@ -0,0 +1,77 @@
+package main
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Indexing-position metrics are labeled by token address and symbol so a
+// process indexing several wrapped assets exposes one series per token and
+// dashboards can see which one is lagging. Cardinality stays bounded because
+// series are only ever created for the configured token set — labels come
+// from config, never from chain data.
+var (
+	lastProcessedGauge = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "tracker_last_processed_block",
+		Help: "Highest block fully processed, per token.",
+	}, []string{"token", "symbol"})
+
+	blockLagGauge = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "tracker_block_lag",
+		Help: "Blocks between the chain head and the last processed block, per token.",
+	}, []string{"token", "symbol"})
+
+	holderCountGauge = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "tracker_holder_count",
+		Help: "Number of addresses with a nonzero tracked balance at or above HOLDER_MIN_BALANCE, per token.",
+	}, []string{"token", "symbol"})
+)
+
+// holderCountInterval rate-limits the COUNT(*) behind the holder-count gauge;
+// the position gauges are cheap and update every iteration.
+const holderCountInterval = 30 * time.Second
+
+var holderCountRefresh struct {
+	sync.Mutex
+	last time.Time
+}
+
+// updatePositionMetrics sets the per-token series the loop maintains.
+func updatePositionMetrics(token, symbol string, lastProcessed, latest uint64) {
+	lastProcessedGauge.WithLabelValues(token, symbol).Set(float64(lastProcessed))
+	lag := uint64(0)
+	if latest > lastProcessed {
+		lag = latest - lastProcessed
+	}
+	blockLagGauge.WithLabelValues(token, symbol).Set(float64(lag))
+
+	holderCountRefresh.Lock()
+	due := time.Since(holderCountRefresh.last) >= holderCountInterval
+	if due {
+		holderCountRefresh.last = time.Now()
+	}
+	holderCountRefresh.Unlock()
+	if !due {
+		return
+	}
+	count, err := visibleHolderCount()
+	if err != nil {
+		log.Printf("Failed to count holders for metrics: %v", err)
+		return
+	}
+	holderCountGauge.WithLabelValues(token, symbol).Set(float64(count))
+}
+
+// visibleHolderCount counts the addresses the holder-count metric reports:
+// nonzero balances at or above HOLDER_MIN_BALANCE. Dust below the threshold
+// stays in the table and stays queryable; it just isn't counted.
+func visibleHolderCount() (int64, error) {
+	var count int64
+	expr := balanceExpr()
+	err := db.QueryRow(q("SELECT COUNT(*) FROM wbtc_holders WHERE "+expr+" != 0 AND "+expr+" >= ?"), minHolderBalance).Scan(&count)
+	return count, err
+}
@@ -0,0 +1,318 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// Versioned schema migrations. Every static table and index the tracker
+// uses is created here, in order, with the applied versions recorded in
+// schema_migrations — so a schema change ships as a new numbered entry
+// instead of another CREATE TABLE scattered into an init function, and an
+// operator can read back exactly which schema a database is on.
+//
+// Three properties keep adoption and upgrades safe:
+//
+//   - Every statement is individually idempotent (IF NOT EXISTS, or an
+//     ALTER whose duplicate-column error is tolerated), so a database
+//     created by an earlier binary — which already has all of these
+//     tables — walks the whole list as no-ops and just gains the version
+//     rows, and a crash mid-migration simply re-runs on the next start.
+//     Because of that, statements run outside a transaction: a tolerated
+//     error would poison a postgres transaction.
+//
+//   - Tables are created whether or not their feature is enabled. An
+//     empty table is harmless, and it means enabling a feature later is a
+//     config change, not a schema change.
+//
+//   - The {{amount}} placeholder expands to amountColumnDef() at apply
+//     time, so amount columns keep matching the balance schema of the
+//     deployment they join. Balance storage detection runs as soon as the
+//     first migration has guaranteed wbtc_holders exists.
+//
+// Per-contract and per-chain holder tables (holders_<address>,
+// chain_holders_<name>) are named from runtime configuration and cannot
+// be versioned ahead of time; they remain with their features.
+//
+// Migrations are append-only: never edit or reorder a shipped entry, add
+// a new version.
+type migration struct {
+	version    int
+	name       string
+	statements []string
+}
+
+var schemaMigrations = []migration{
+	{1, "core", []string{
+		`CREATE TABLE IF NOT EXISTS wbtc_holders (
+			address TEXT PRIMARY KEY,
+			balance TEXT NOT NULL DEFAULT '0',
+			first_seen_block INTEGER NOT NULL DEFAULT 0,
+			last_active_block INTEGER NOT NULL DEFAULT 0
+		)`,
+		`CREATE TABLE IF NOT EXISTS tracker_state (
+			key TEXT PRIMARY KEY,
+			value TEXT NOT NULL
+		)`,
+	}},
+	// Databases created before the activity columns existed get them added
+	// here; existing rows keep the 0 default until backfill-columns fills
+	// them.
+	{2, "holder_activity_columns", []string{
+		`ALTER TABLE wbtc_holders ADD COLUMN first_seen_block INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE wbtc_holders ADD COLUMN last_active_block INTEGER NOT NULL DEFAULT 0`,
+	}},
+	{3, "event_bookkeeping", []string{
+		`CREATE TABLE IF NOT EXISTS processed_events (
+			tx_hash TEXT NOT NULL,
+			log_index INTEGER NOT NULL,
+			block INTEGER NOT NULL,
+			PRIMARY KEY (tx_hash, log_index)
+		)`,
+		`CREATE TABLE IF NOT EXISTS processed_blocks (
+			block INTEGER PRIMARY KEY,
+			hash TEXT NOT NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS decode_failures (
+			tx_hash TEXT NOT NULL,
+			log_index INTEGER NOT NULL,
+			block INTEGER NOT NULL,
+			event TEXT NOT NULL,
+			topics TEXT NOT NULL,
+			data TEXT NOT NULL,
+			PRIMARY KEY (tx_hash, log_index)
+		)`,
+	}},
+	// The allowances amount is always TEXT regardless of the balance
+	// schema: allowances are routinely the infinite 2^256-1 and nothing
+	// does arithmetic on them.
+	{4, "bridge_accounting", []string{
+		`CREATE TABLE IF NOT EXISTS epochs (
+			epoch INTEGER PRIMARY KEY,
+			block_number INTEGER NOT NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS bridge_balances (
+			address TEXT PRIMARY KEY,
+			amount {{amount}}
+		)`,
+		`CREATE TABLE IF NOT EXISTS allowances (
+			owner TEXT NOT NULL,
+			spender TEXT NOT NULL,
+			amount TEXT NOT NULL,
+			block INTEGER NOT NULL,
+			PRIMARY KEY (owner, spender)
+		)`,
+		`CREATE TABLE IF NOT EXISTS contract_upgrades (
+			block INTEGER NOT NULL,
+			tx_hash TEXT NOT NULL,
+			implementation TEXT NOT NULL,
+			PRIMARY KEY (block, tx_hash)
+		)`,
+	}},
+	{5, "bridge_ledger", []string{
+		`CREATE TABLE IF NOT EXISTS mints (
+			tx_hash TEXT NOT NULL,
+			log_index INTEGER NOT NULL,
+			block INTEGER NOT NULL,
+			to_address TEXT NOT NULL,
+			btc_txid TEXT NOT NULL,
+			amount {{amount}},
+			PRIMARY KEY (tx_hash, log_index)
+		)`,
+		`CREATE INDEX IF NOT EXISTS mints_block ON mints (block)`,
+		`CREATE TABLE IF NOT EXISTS redemptions (
+			tx_hash TEXT NOT NULL,
+			log_index INTEGER NOT NULL,
+			block INTEGER NOT NULL,
+			from_address TEXT NOT NULL,
+			btc_address TEXT NOT NULL,
+			amount {{amount}},
+			PRIMARY KEY (tx_hash, log_index)
+		)`,
+		`CREATE INDEX IF NOT EXISTS redemptions_block ON redemptions (block)`,
+	}},
+	{6, "transfer_ledger", []string{
+		`CREATE TABLE IF NOT EXISTS transfers (
+			tx_hash TEXT NOT NULL,
+			log_index INTEGER NOT NULL,
+			block INTEGER NOT NULL,
+			event TEXT NOT NULL,
+			from_address TEXT NOT NULL,
+			to_address TEXT NOT NULL DEFAULT '',
+			value {{amount}},
+			timestamp INTEGER NOT NULL DEFAULT 0,
+			PRIMARY KEY (tx_hash, log_index)
+		)`,
+		`CREATE INDEX IF NOT EXISTS transfers_block ON transfers (block)`,
+		`ALTER TABLE transfers ADD COLUMN timestamp INTEGER NOT NULL DEFAULT 0`,
+	}},
+	{7, "daily_stats", []string{
+		`CREATE TABLE IF NOT EXISTS daily_stats (
+			date TEXT PRIMARY KEY,
+			transfer_count INTEGER NOT NULL DEFAULT 0,
+			volume INTEGER NOT NULL DEFAULT 0,
+			active_addresses INTEGER NOT NULL DEFAULT 0
+		)`,
+		`CREATE TABLE IF NOT EXISTS daily_active (
+			date TEXT NOT NULL,
+			address TEXT NOT NULL,
+			PRIMARY KEY (date, address)
+		)`,
+	}},
+	{8, "history_and_snapshots", []string{
+		`CREATE TABLE IF NOT EXISTS balance_history (
+			address TEXT NOT NULL,
+			block INTEGER NOT NULL,
+			balance {{amount}},
+			PRIMARY KEY (address, block)
+		)`,
+		`CREATE TABLE IF NOT EXISTS balance_snapshots (
+			block INTEGER NOT NULL,
+			address TEXT NOT NULL,
+			balance {{amount}},
+			PRIMARY KEY (block, address)
+		)`,
+	}},
+	{9, "names_and_metadata", []string{
+		`CREATE TABLE IF NOT EXISTS token_metadata (
+			token TEXT PRIMARY KEY,
+			decimals INTEGER NOT NULL,
+			symbol TEXT NOT NULL DEFAULT ''
+		)`,
+		`CREATE TABLE IF NOT EXISTS ens_names (
+			address TEXT PRIMARY KEY,
+			name TEXT NOT NULL,
+			resolved_at INTEGER NOT NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS address_labels (
+			address TEXT PRIMARY KEY,
+			label TEXT NOT NULL
+		)`,
+	}},
+	{10, "distribution", []string{
+		`CREATE TABLE IF NOT EXISTS distribution_daily (
+			date TEXT PRIMARY KEY,
+			holders INTEGER NOT NULL,
+			top10_share REAL NOT NULL,
+			gini REAL NOT NULL
+		)`,
+	}},
+	{11, "block_times", []string{
+		`CREATE TABLE IF NOT EXISTS block_times (
+			block INTEGER PRIMARY KEY,
+			ts INTEGER NOT NULL
+		)`,
+	}},
+	{12, "mempool", []string{
+		`CREATE TABLE IF NOT EXISTS pending_transfers (
+			tx_hash TEXT PRIMARY KEY,
+			kind TEXT NOT NULL,
+			from_address TEXT NOT NULL,
+			to_address TEXT NOT NULL,
+			amount {{amount}},
+			seen_at INTEGER NOT NULL
+		)`,
+	}},
+	{13, "merkle_roots", []string{
+		`CREATE TABLE IF NOT EXISTS merkle_roots (
+			block INTEGER PRIMARY KEY,
+			root TEXT NOT NULL,
+			holders INTEGER NOT NULL,
+			computed_at INTEGER NOT NULL
+		)`,
+	}},
+	{14, "dust_archive", []string{
+		`CREATE TABLE IF NOT EXISTS wbtc_holders_archive (
+			address TEXT PRIMARY KEY,
+			balance {{amount}},
+			archived_at INTEGER NOT NULL
+		)`,
+	}},
+	{15, "custom_events", []string{
+		`CREATE TABLE IF NOT EXISTS custom_events (
+			tx_hash TEXT NOT NULL,
+			log_index INTEGER NOT NULL,
+			block INTEGER NOT NULL,
+			event TEXT NOT NULL,
+			fields TEXT NOT NULL,
+			amount {{amount}},
+			PRIMARY KEY (tx_hash, log_index)
+		)`,
+		`CREATE INDEX IF NOT EXISTS custom_events_name_block ON custom_events (event, block)`,
+	}},
+	{16, "operator_gas", []string{
+		`CREATE TABLE IF NOT EXISTS operator_gas (
+			checked_at INTEGER PRIMARY KEY,
+			balance TEXT NOT NULL
+		)`,
+	}},
+	{17, "spot_checks", []string{
+		`CREATE TABLE IF NOT EXISTS spot_check_discrepancies (
+			address TEXT NOT NULL,
+			block INTEGER NOT NULL,
+			indexed TEXT NOT NULL,
+			on_chain TEXT NOT NULL,
+			delta TEXT NOT NULL,
+			checked_at INTEGER NOT NULL,
+			PRIMARY KEY (address, block)
+		)`,
+	}},
+}
+
+// runMigrations brings the schema to the current version at startup.
+func runMigrations() error {
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		name TEXT NOT NULL,
+		applied_at INTEGER NOT NULL
+	)`)
+	if err != nil {
+		return fmt.Errorf("create schema_migrations table: %w", err)
+	}
+	for i, m := range schemaMigrations {
+		if err := applyMigration(m); err != nil {
+			return err
+		}
+		if i == 0 {
+			// Later entries size amount columns off the balance schema,
+			// which can only be inspected once the core tables exist.
+			detectBalanceStorage()
+		}
+	}
+	return nil
+}
+
+func applyMigration(m migration) error {
+	var one int
+	err := db.QueryRow(q(`SELECT 1 FROM schema_migrations WHERE version = ?`), m.version).Scan(&one)
+	if err == nil {
+		return nil
+	}
+	if err != sql.ErrNoRows {
+		return fmt.Errorf("check migration %d: %w", m.version, err)
+	}
+	for _, statement := range m.statements {
+		statement = strings.ReplaceAll(statement, "{{amount}}", amountColumnDef())
+		if _, err := db.Exec(statement); err != nil {
+			// The expected case for the ALTERs on every database that
+			// already has the column: sqlite says "duplicate column",
+			// postgres "already exists".
+			lower := strings.ToLower(err.Error())
+			if strings.HasPrefix(statement, "ALTER TABLE") &&
+				(strings.Contains(lower, "duplicate column") || strings.Contains(lower, "already exists")) {
+				continue
+			}
+			return fmt.Errorf("migration %d (%s): %w", m.version, m.name, err)
+		}
+	}
+	_, err = db.Exec(q(`INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)`),
+		m.version, m.name, time.Now().Unix())
+	if err != nil {
+		return fmt.Errorf("record migration %d: %w", m.version, err)
+	}
+	log.Printf("Applied schema migration %d (%s)", m.version, m.name)
+	return nil
+}
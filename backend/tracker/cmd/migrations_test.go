@@ -0,0 +1,105 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMigrationsApplyAndRecord(t *testing.T) {
+	setupTestDB(t)
+	var applied int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM schema_migrations`).Scan(&applied); err != nil {
+		t.Fatalf("count applied migrations: %v", err)
+	}
+	if applied != len(schemaMigrations) {
+		t.Errorf("applied %d migrations, want %d", applied, len(schemaMigrations))
+	}
+	// A second run must be a no-op, including the tolerated ALTERs.
+	if err := runMigrations(); err != nil {
+		t.Fatalf("re-run migrations: %v", err)
+	}
+	if err := db.QueryRow(`SELECT COUNT(*) FROM schema_migrations`).Scan(&applied); err != nil {
+		t.Fatalf("count applied migrations: %v", err)
+	}
+	if applied != len(schemaMigrations) {
+		t.Errorf("re-run recorded %d migrations, want %d", applied, len(schemaMigrations))
+	}
+}
+
+// The test suite runs on SQLite only, which happily accepts identifiers
+// PostgreSQL reserves — bridge_balances once used a `user` column that
+// passed every test and broke Postgres at CREATE TABLE. Guard the
+// migration DDL against PostgreSQL's fully reserved words so the next
+// such column is caught here instead of in a Postgres deployment.
+var postgresReservedWords = map[string]bool{
+	"all": true, "analyse": true, "analyze": true, "and": true, "any": true,
+	"array": true, "as": true, "asc": true, "asymmetric": true, "both": true,
+	"case": true, "cast": true, "check": true, "collate": true, "column": true,
+	"constraint": true, "create": true, "current_catalog": true,
+	"current_date": true, "current_role": true, "current_time": true,
+	"current_timestamp": true, "current_user": true, "default": true,
+	"deferrable": true, "desc": true, "distinct": true, "do": true,
+	"else": true, "end": true, "except": true, "false": true, "fetch": true,
+	"for": true, "foreign": true, "from": true, "grant": true, "group": true,
+	"having": true, "in": true, "initially": true, "intersect": true,
+	"into": true, "lateral": true, "leading": true, "limit": true,
+	"localtime": true, "localtimestamp": true, "not": true, "null": true,
+	"offset": true, "on": true, "only": true, "or": true, "order": true,
+	"placing": true, "primary": true, "references": true, "returning": true,
+	"select": true, "session_user": true, "some": true, "symmetric": true,
+	"table": true, "then": true, "to": true, "trailing": true, "true": true,
+	"union": true, "unique": true, "user": true, "using": true,
+	"variadic": true, "when": true, "where": true, "window": true, "with": true,
+}
+
+func TestMigrationColumnsAvoidReservedWords(t *testing.T) {
+	for _, m := range schemaMigrations {
+		for _, statement := range m.statements {
+			for _, column := range ddlColumnNames(statement) {
+				if postgresReservedWords[strings.ToLower(column)] {
+					t.Errorf("migration %d (%s): column %q is a reserved word in PostgreSQL", m.version, m.name, column)
+				}
+			}
+		}
+	}
+}
+
+// ddlColumnNames extracts the column identifiers a migration statement
+// defines: one per body line of a CREATE TABLE, or the added column of an
+// ALTER TABLE ... ADD COLUMN. The statements are formatted one column per
+// line, which is what this lean parser relies on.
+func ddlColumnNames(statement string) []string {
+	if strings.HasPrefix(statement, "ALTER TABLE") {
+		fields := strings.Fields(statement)
+		if len(fields) >= 6 && fields[3] == "ADD" && fields[4] == "COLUMN" {
+			return []string{fields[5]}
+		}
+		return nil
+	}
+	if !strings.HasPrefix(statement, "CREATE TABLE") {
+		return nil
+	}
+	var columns []string
+	lines := strings.Split(statement, "\n")
+	for _, line := range lines[1 : len(lines)-1] {
+		first := strings.Fields(strings.TrimSpace(line))[0]
+		switch strings.ToUpper(first) {
+		case "PRIMARY", "UNIQUE", "FOREIGN", "CHECK", "CONSTRAINT":
+			continue
+		}
+		columns = append(columns, first)
+	}
+	return columns
+}
+
+// Shipped migrations are append-only: versions must be unique and strictly
+// ascending so the recorded history stays meaningful.
+func TestMigrationVersionsAscending(t *testing.T) {
+	for i := 1; i < len(schemaMigrations); i++ {
+		if schemaMigrations[i].version <= schemaMigrations[i-1].version {
+			t.Errorf("migration %q (version %d) does not follow %q (version %d)",
+				schemaMigrations[i].name, schemaMigrations[i].version,
+				schemaMigrations[i-1].name, schemaMigrations[i-1].version)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"encoding/hex"
+	"log"
+	"math/big"
+	"net/http"
+	"strings"
+	"unicode"
+
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// Every TokensMinted event is the ETH side of a BTC deposit: the bridge
+// mints WBTC after observing the bitcoin transaction, and the event's data
+// payload carries that originating BTC txid. Persisting mints alongside
+// redemptions means the full bridge ledger — in and out — reconstructs from
+// chain data alone.
+
+// decodeBTCTxid extracts the originating bitcoin txid from the mint's data
+// payload. The bridge has emitted it both as ASCII hex and as the raw
+// 32-byte txid; accept either, normalizing the raw form to hex. Anything
+// else comes back "" rather than being stored as garbage.
+func decodeBTCTxid(data []byte) string {
+	trimmed := strings.TrimRight(string(data), "\x00")
+	printable := true
+	for _, r := range trimmed {
+		if r > unicode.MaxASCII || !unicode.IsPrint(r) {
+			printable = false
+			break
+		}
+	}
+	if printable && trimmed != "" {
+		return trimmed
+	}
+	if len(data) == 32 {
+		return hex.EncodeToString(data)
+	}
+	return ""
+}
+
+// recordMint persists one mint. Replayed logs hit the primary key and are
+// ignored, same as the transfer ledger.
+func recordMint(vLog types.Log, to string, amount *big.Int, data []byte) {
+	txid := decodeBTCTxid(data)
+	if txid == "" {
+		log.Printf("TokensMinted log (tx %s, index %d) has no decodable BTC txid", vLog.TxHash.Hex(), vLog.Index)
+	}
+	_, err := db.Exec(q(`INSERT INTO mints (tx_hash, log_index, block, to_address, btc_txid, amount)
+		VALUES (?, ?, ?, ?, ?, ?) ON CONFLICT(tx_hash, log_index) DO NOTHING`),
+		vLog.TxHash.Hex(), vLog.Index, vLog.BlockNumber, to, txid, amount.String())
+	if err != nil {
+		log.Printf("Failed to record mint (tx %s, index %d): %v", vLog.TxHash.Hex(), vLog.Index, err)
+	}
+}
+
+// handleMints serves GET /mints?limit=: recent mints with their originating
+// BTC txids, newest first.
+func handleMints(w http.ResponseWriter, r *http.Request) {
+	limit := envQueryUint(r, "limit", 100)
+	if limit > 1000 {
+		limit = 1000
+	}
+	rows, err := readDB.Query(q(`SELECT tx_hash, log_index, block, to_address, btc_txid, amount
+		FROM mints ORDER BY block DESC, log_index DESC LIMIT ?`), limit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	type mint struct {
+		TxHash    string `json:"tx_hash"`
+		LogIndex  uint   `json:"log_index"`
+		Block     uint64 `json:"block"`
+		Timestamp uint64 `json:"timestamp,omitempty"`
+		To        string `json:"to"`
+		BTCTxid   string `json:"btc_txid"`
+		Amount    string `json:"amount"`
+	}
+	mints := []mint{}
+	blocks := []uint64{}
+	for rows.Next() {
+		var item mint
+		if err := rows.Scan(&item.TxHash, &item.LogIndex, &item.Block, &item.To, &item.BTCTxid, &item.Amount); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		mints = append(mints, item)
+		blocks = append(blocks, item.Block)
+	}
+	if err := rows.Err(); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	times := storedBlockTimes(blocks)
+	for i := range mints {
+		mints[i].Timestamp = times[mints[i].Block]
+	}
+	writeJSON(w, map[string]interface{}{
+		"mints": mints,
+	})
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// Multi-token tracking. TOKENS_CONFIG holds a JSON list of additional ERC-20
+// contracts to index alongside the primary WBTC_ADDRESS pipeline:
+//
+//	[{"address":"0x…","symbol":"WETH","starting_block":12345}]
+//
+// Each extra token gets its own cursor (a tracker_state key scoped by
+// address), its own holders table, and its own indexing goroutine running
+// the same windowed Transfer scan the main loop uses. The primary token
+// keeps the full feature set (ledger, history, redemptions, API); extra
+// tokens get balances and position metrics, which is what fleet monitoring
+// of several wrapped assets actually needs.
+type tokenConfig struct {
+	Address       string `json:"address"`
+	Symbol        string `json:"symbol"`
+	StartingBlock uint64 `json:"starting_block"`
+}
+
+func initExtraTokens(client *ethclient.Client) {
+	raw := os.Getenv("TOKENS_CONFIG")
+	if raw == "" {
+		return
+	}
+	var configs []tokenConfig
+	if err := json.Unmarshal([]byte(raw), &configs); err != nil {
+		log.Fatalf("Invalid TOKENS_CONFIG: %v", err)
+	}
+	primary := common.HexToAddress(os.Getenv("WBTC_ADDRESS")).Hex()
+	for _, cfg := range configs {
+		if !common.IsHexAddress(cfg.Address) {
+			log.Fatalf("TOKENS_CONFIG contains invalid address %q", cfg.Address)
+		}
+		address := common.HexToAddress(cfg.Address).Hex()
+		if address == primary {
+			continue
+		}
+		table := extraTokenTable(address)
+		_, err := db.Exec(`CREATE TABLE IF NOT EXISTS ` + table + ` (
+			address TEXT PRIMARY KEY,
+			balance ` + amountColumnDef() + `,
+			last_active_block INTEGER NOT NULL DEFAULT 0
+		)`)
+		if err != nil {
+			log.Fatalf("Failed to create %s table: %v", table, err)
+		}
+		log.Printf("Tracking extra token %s (%s) from block %d", address, cfg.Symbol, cfg.StartingBlock)
+		go trackExtraToken(client, address, cfg.Symbol, cfg.StartingBlock)
+	}
+}
+
+// extraTokenTable names the per-contract holders table. The address has been
+// through HexToAddress, so the identifier is hex-only and safe to splice.
+func extraTokenTable(address string) string {
+	return "holders_" + strings.ToLower(strings.TrimPrefix(address, "0x"))
+}
+
+func extraTokenCursorKey(address string) string {
+	return "last_processed_block:" + address
+}
+
+func loadExtraTokenCursor(address string, startingBlock uint64) uint64 {
+	var value string
+	err := db.QueryRow(q("SELECT value FROM tracker_state WHERE key = ?"), extraTokenCursorKey(address)).Scan(&value)
+	if err == sql.ErrNoRows {
+		return startingBlock
+	}
+	if err != nil {
+		log.Fatalf("Failed to load cursor for %s: %v", address, err)
+	}
+	block, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		log.Fatalf("Corrupt cursor %q for %s: %v", value, address, err)
+	}
+	return block
+}
+
+func saveExtraTokenCursor(address string, block uint64) {
+	_, err := db.Exec(q(`INSERT INTO tracker_state (key, value) VALUES (?, ?)
+		ON CONFLICT(key) DO UPDATE SET value = excluded.value`),
+		extraTokenCursorKey(address), strconv.FormatUint(block, 10))
+	if err != nil {
+		log.Printf("Failed to save cursor for %s: %v", address, err)
+	}
+}
+
+// applyExtraTokenDelta is updateBalance reduced to what extra tokens track:
+// the delta upsert, without ledger, history, or cache hooks. It follows the
+// same storage split: big-integer read-modify-write on TEXT schemas, SQL
+// arithmetic (with an int64 guard) on numeric ones.
+func applyExtraTokenDelta(table, address string, delta *big.Int, block uint64) {
+	if balanceTextStorage {
+		balanceTextMu.Lock()
+		defer balanceTextMu.Unlock()
+		var stored string
+		err := db.QueryRow(q(`SELECT balance FROM `+table+` WHERE address = ?`), address).Scan(&stored)
+		current := new(big.Int)
+		switch {
+		case err == nil:
+			if _, ok := current.SetString(stored, 10); !ok {
+				log.Printf("Corrupt balance %q for %s in %s", stored, address, table)
+				return
+			}
+		case err == sql.ErrNoRows:
+			// New holder; current stays zero.
+		default:
+			log.Printf("Failed to read balance for %s in %s: %v", address, table, err)
+			return
+		}
+		current.Add(current, delta)
+		if _, err := db.Exec(q(`INSERT INTO `+table+` (address, balance, last_active_block) VALUES (?, ?, ?)
+			ON CONFLICT(address) DO UPDATE SET balance = excluded.balance,
+				last_active_block = excluded.last_active_block`),
+			address, current.String(), block); err != nil {
+			log.Printf("Failed to apply delta for %s in %s: %v", address, table, err)
+		}
+		return
+	}
+	if !delta.IsInt64() {
+		reportIntegrityViolation(fmt.Sprintf("delta %s for %s in %s does not fit the numeric balance schema; migrate balances to TEXT storage", delta, address, table))
+		return
+	}
+	_, err := db.Exec(q(`INSERT INTO `+table+` (address, balance, last_active_block) VALUES (?, ?, ?)
+		ON CONFLICT(address) DO UPDATE SET balance = `+table+`.balance + excluded.balance,
+			last_active_block = excluded.last_active_block`), address, delta.Int64(), block)
+	if err != nil {
+		log.Printf("Failed to apply delta for %s in %s: %v", address, table, err)
+	}
+}
+
+// trackExtraToken is the per-contract indexing loop: the same windowed scan
+// as updateHolders, but only for Transfer events and only into the token's
+// own holders table.
+func trackExtraToken(client *ethclient.Client, address, symbol string, startingBlock uint64) {
+	contract := common.HexToAddress(address)
+	table := extraTokenTable(address)
+	transferTopic := contractABI.Events["Transfer"].ID
+	window := newAdaptiveWindow()
+	// Extra tokens honor the same CONFIRMATIONS lag as the primary loop; a
+	// bridge that waits out shallow reorgs should not see them through a
+	// side table either.
+	confirmations := envUint("CONFIRMATIONS", 0)
+	lastProcessed := loadExtraTokenCursor(address, startingBlock)
+
+	var latest uint64
+	for {
+		if stopRequested.Load() {
+			return
+		}
+		if paused, _ := indexingPaused(); paused {
+			sleepOrShutdown(15 * time.Second)
+			continue
+		}
+		client = activeEthClient()
+		latest = refreshLatestBlock(func() (uint64, error) {
+			return client.BlockNumber(rootCtx)
+		}, latest)
+		target := computeTargetBlock(latest, confirmations)
+		if latest == 0 || lastProcessed >= target {
+			updatePositionMetrics(contract.Hex(), symbol, lastProcessed, latest)
+			sleepOrShutdown(15 * time.Second)
+			continue
+		}
+
+		fromBlock := lastProcessed + 1
+		toBlock := fromBlock + window.size() - 1
+		if toBlock > target {
+			toBlock = target
+		}
+		logs, err := client.FilterLogs(rootCtx, ethereum.FilterQuery{
+			FromBlock: new(big.Int).SetUint64(fromBlock),
+			ToBlock:   new(big.Int).SetUint64(toBlock),
+			Addresses: []common.Address{contract},
+			Topics:    [][]common.Hash{{transferTopic}},
+		})
+		if err != nil {
+			if window.shrinkOnError(err) {
+				continue
+			}
+			logDeduped("Failed to filter logs for "+address, err)
+			noteRPCFailure()
+			sleepOrShutdown(15 * time.Second)
+			continue
+		}
+		clearLogDedupe("Failed to filter logs for " + address)
+		noteRPCSuccess()
+		window.observe(len(logs))
+
+		for _, vLog := range logs {
+			if len(vLog.Topics) < 3 {
+				continue
+			}
+			var transferEvent struct {
+				Value *big.Int
+			}
+			if err := decodeEvent("Transfer", &transferEvent, vLog); err != nil {
+				log.Printf("Skipping undecodable Transfer log for %s (tx %s): %v", address, vLog.TxHash.Hex(), err)
+				continue
+			}
+			from := common.HexToAddress(vLog.Topics[1].Hex()).Hex()
+			to := common.HexToAddress(vLog.Topics[2].Hex()).Hex()
+			amount := transferEvent.Value
+			if filteredTransfer(from, to, amount) {
+				continue
+			}
+			if from == to {
+				continue
+			}
+			if from != zeroAddress {
+				applyExtraTokenDelta(table, from, new(big.Int).Neg(amount), vLog.BlockNumber)
+			}
+			if to != zeroAddress {
+				applyExtraTokenDelta(table, to, amount, vLog.BlockNumber)
+			}
+		}
+		lastProcessed = toBlock
+		saveExtraTokenCursor(address, lastProcessed)
+		updatePositionMetrics(contract.Hex(), symbol, lastProcessed, latest)
+	}
+}
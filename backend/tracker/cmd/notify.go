@@ -0,0 +1,168 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log"
+	"math/big"
+	"net/http"
+	"os"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Notifications are governed by a per-type, per-token config matrix so a
+// noisy token can be quieted without touching the rest. Each rule carries
+// enabled, an amount threshold in base units (0 = always) and a severity.
+// Integrity and reorg notifications are deliberately exempt from disabling:
+// those are the ones that page someone. The matrix loads from
+// NOTIFICATIONS_CONFIG (JSON) and can be replaced at runtime through
+// /admin/notifications, so thresholds are tunable without a restart.
+type notificationRule struct {
+	Enabled   bool   `json:"enabled"`
+	Threshold int64  `json:"threshold"`
+	Severity  string `json:"severity"`
+}
+
+// notificationMatrix maps notification type -> token address (or "*") -> rule.
+type notificationMatrix map[string]map[string]notificationRule
+
+// criticalNotifications always fire regardless of the matrix.
+var criticalNotifications = map[string]bool{
+	"integrity": true,
+	"reorg":     true,
+}
+
+var notifyState struct {
+	sync.RWMutex
+	matrix notificationMatrix
+}
+
+func initNotifications() {
+	raw := os.Getenv("NOTIFICATIONS_CONFIG")
+	if raw == "" {
+		return
+	}
+	matrix, err := parseNotificationMatrix([]byte(raw))
+	if err != nil {
+		log.Fatalf("Invalid NOTIFICATIONS_CONFIG: %v", err)
+	}
+	notifyState.Lock()
+	notifyState.matrix = matrix
+	notifyState.Unlock()
+}
+
+func parseNotificationMatrix(raw []byte) (notificationMatrix, error) {
+	var matrix notificationMatrix
+	if err := json.Unmarshal(raw, &matrix); err != nil {
+		return nil, err
+	}
+	return matrix, nil
+}
+
+// notifyToken is the token key call sites pass for the tracked contract.
+func notifyToken() string {
+	return common.HexToAddress(os.Getenv("WBTC_ADDRESS")).Hex()
+}
+
+// notificationRuleFor resolves the rule for a type/token pair: exact token
+// entry first, then the "*" wildcard, then disabled.
+func notificationRuleFor(kind, token string) (notificationRule, bool) {
+	notifyState.RLock()
+	defer notifyState.RUnlock()
+	byToken, ok := notifyState.matrix[kind]
+	if !ok {
+		return notificationRule{}, false
+	}
+	if rule, ok := byToken[token]; ok {
+		return rule, true
+	}
+	rule, ok := byToken["*"]
+	return rule, ok
+}
+
+// dispatchNotification fires one notification if the matrix allows it:
+// critical types always go out, others need an enabled rule whose threshold
+// the amount meets. Notifications without a meaningful amount pass nil.
+// Delivery is a log line and, when WEBHOOK_URL is set, a JSON POST; webhook
+// failures only log.
+func dispatchNotification(kind, token, message string, amount *big.Int) {
+	if amount == nil {
+		amount = big.NewInt(0)
+	}
+	severity := "critical"
+	if !criticalNotifications[kind] {
+		rule, ok := notificationRuleFor(kind, token)
+		if !ok || !rule.Enabled {
+			return
+		}
+		if rule.Threshold > 0 && amount.Cmp(big.NewInt(rule.Threshold)) < 0 {
+			return
+		}
+		severity = rule.Severity
+		if severity == "" {
+			severity = "info"
+		}
+	}
+	log.Printf("NOTIFY [%s] %s %s: %s", severity, kind, token, message)
+
+	webhook := os.Getenv("WEBHOOK_URL")
+	if webhook == "" {
+		return
+	}
+	payload, err := json.Marshal(map[string]interface{}{
+		"type":     kind,
+		"token":    token,
+		"severity": severity,
+		"message":  message,
+		"amount":   amount.String(),
+	})
+	if err != nil {
+		return
+	}
+	resp, err := http.Post(webhook, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		log.Printf("Failed to deliver %s notification: %v", kind, err)
+		return
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+}
+
+// handleAdminNotifications serves GET/POST /admin/notifications: read the
+// active matrix or replace it wholesale without a restart.
+func handleAdminNotifications(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		notifyState.RLock()
+		matrix := notifyState.matrix
+		notifyState.RUnlock()
+		if matrix == nil {
+			matrix = notificationMatrix{}
+		}
+		writeJSON(w, map[string]interface{}{"notifications": matrix})
+	case http.MethodPost:
+		body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		matrix, err := parseNotificationMatrix(body)
+		if err != nil {
+			http.Error(w, "invalid notification config: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		notifyState.Lock()
+		notifyState.matrix = matrix
+		notifyState.Unlock()
+		log.Printf("Notification config reloaded (%d types)", len(matrix))
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
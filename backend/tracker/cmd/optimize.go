@@ -0,0 +1,77 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Long-running SQLite deployments accumulate free pages that slow the holder
+// listing; Postgres tables bloat the same way. POST /admin/optimize runs the
+// engine's vacuum on demand so operators can schedule it into low-traffic
+// windows. A mutex serializes calls — VACUUM takes its own locks and two
+// concurrent runs would just queue behind each other anyway.
+var optimizeMu sync.Mutex
+
+// databaseSize returns the database's on-disk size in bytes.
+func databaseSize() (int64, error) {
+	if dbType == "postgres" {
+		var size int64
+		err := db.QueryRow("SELECT pg_database_size(current_database())").Scan(&size)
+		return size, err
+	}
+	var pageCount, pageSize int64
+	if err := db.QueryRow("PRAGMA page_count").Scan(&pageCount); err != nil {
+		return 0, err
+	}
+	if err := db.QueryRow("PRAGMA page_size").Scan(&pageSize); err != nil {
+		return 0, err
+	}
+	return pageCount * pageSize, nil
+}
+
+func handleAdminOptimize(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	optimizeMu.Lock()
+	defer optimizeMu.Unlock()
+
+	before, err := databaseSize()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	start := time.Now()
+	// VACUUM cannot run inside a transaction; plain Execs on the pool are
+	// fine because the driver waits for in-flight write transactions to
+	// finish rather than deadlocking against them.
+	if dbType == "postgres" {
+		_, err = db.Exec("VACUUM ANALYZE")
+	} else {
+		if _, err = db.Exec("VACUUM"); err == nil {
+			_, err = db.Exec("ANALYZE")
+		}
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	after, serr := databaseSize()
+	if serr != nil {
+		http.Error(w, serr.Error(), http.StatusInternalServerError)
+		return
+	}
+	log.Printf("Database optimize finished in %s: %d -> %d bytes", time.Since(start).Round(time.Millisecond), before, after)
+	writeJSON(w, map[string]interface{}{
+		"before_bytes": before,
+		"after_bytes":  after,
+		"freed_bytes":  before - after,
+		"duration_ms":  time.Since(start).Milliseconds(),
+	})
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Mint/burn velocity is what surfaces a redemption run or a minting anomaly
+// before the balances look wrong. The handlers feed every mint and burn into
+// an in-memory ring of recent events; gauges export the count and volume
+// inside a rolling RATE_WINDOW (default 1h). The window is maintained by
+// pruning on each event, never by scanning the DB. Volume is a big-integer
+// sum downcast to float64 only at export, so the metric loses precision but
+// the arithmetic never overflows.
+var (
+	mintBurnRateCount = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "mint_burn_rate_count",
+		Help: "Number of mint/burn events inside the rolling RATE_WINDOW, by kind.",
+	}, []string{"kind"})
+	mintBurnRateVolume = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "mint_burn_rate_volume",
+		Help: "Token volume of mint/burn events inside the rolling RATE_WINDOW, by kind.",
+	}, []string{"kind"})
+
+	rateWindow = time.Hour
+
+	rateState struct {
+		sync.Mutex
+		events []rateEvent
+	}
+)
+
+type rateEvent struct {
+	at     time.Time
+	kind   string
+	amount *big.Int
+}
+
+func initRates() {
+	rateWindow = envDuration("RATE_WINDOW", time.Hour)
+}
+
+// noteRateEvent records one mint or burn and refreshes the gauges. Ordinary
+// holder-to-holder transfers are ignored; supply doesn't move.
+func noteRateEvent(kind string, amount *big.Int) {
+	if kind != "mint" && kind != "burn" {
+		return
+	}
+	now := time.Now()
+	cutoff := now.Add(-rateWindow)
+
+	rateState.Lock()
+	events := rateState.events
+	start := 0
+	for start < len(events) && events[start].at.Before(cutoff) {
+		start++
+	}
+	events = append(events[start:], rateEvent{at: now, kind: kind, amount: amount})
+	rateState.events = events
+
+	counts := map[string]float64{"mint": 0, "burn": 0}
+	volumes := map[string]*big.Int{"mint": new(big.Int), "burn": new(big.Int)}
+	for _, e := range events {
+		counts[e.kind]++
+		volumes[e.kind].Add(volumes[e.kind], e.amount)
+	}
+	rateState.Unlock()
+
+	for _, k := range []string{"mint", "burn"} {
+		mintBurnRateCount.WithLabelValues(k).Set(counts[k])
+		volume, _ := new(big.Float).SetInt(volumes[k]).Float64()
+		mintBurnRateVolume.WithLabelValues(k).Set(volume)
+	}
+}
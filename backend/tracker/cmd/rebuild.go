@@ -0,0 +1,51 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+)
+
+// runRebuildBalances implements the rebuild-balances subcommand: truncate
+// wbtc_holders and recompute every balance from the stored transfer
+// ledger, without touching the RPC node. This is the fast recovery path
+// after a balance-logic bug: the ledger records raw events rather than
+// derived balances, so fixing the code and rebuilding replays the
+// corrected arithmetic over the same history. The first_seen/last_active
+// columns come back zeroed; backfill-columns restores them from the same
+// ledger when they matter.
+func runRebuildBalances(args []string) {
+	fs := flag.NewFlagSet("rebuild-balances", flag.ExitOnError)
+	fs.Parse(args)
+
+	openDatabase()
+	initLedger()
+	if !ledgerEnabled {
+		log.Fatalf("rebuild-balances needs the transfer ledger (TRACK_TRANSFERS=true)")
+	}
+
+	holders, err := ledgerHoldersAt(maxBlockParam)
+	if err != nil {
+		log.Fatalf("Failed to recompute balances from ledger: %v", err)
+	}
+
+	if _, err := db.Exec(`DELETE FROM wbtc_holders`); err != nil {
+		log.Fatalf("Failed to truncate wbtc_holders: %v", err)
+	}
+	negative := 0
+	for address, balance := range holders {
+		if balance.Sign() < 0 {
+			negative++
+			log.Printf("WARNING: rebuilt balance of %s is negative (%s); the ledger is missing events", address, balance)
+		}
+		if _, err := db.Exec(q(`INSERT INTO wbtc_holders (address, balance) VALUES (?, ?)`),
+			address, balance.String()); err != nil {
+			log.Fatalf("Failed to write rebuilt balance for %s: %v", address, err)
+		}
+	}
+	fmt.Printf("rebuild-balances: recomputed %d holders from the ledger (%d negative)\n", len(holders), negative)
+	if negative > 0 {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Receipt verification. eth_getLogs is the one RPC answer the tracker
+// takes entirely on faith: a faulty or malicious provider can fabricate a
+// log or quietly drop one and balances follow. With VERIFY_RECEIPTS=true
+// every window is cross-checked before any log dispatches: each block's
+// header bloom says whether the contract could have logged at all, and any
+// block where the bloom is positive or the filter returned something has
+// its receipts fetched and compared log-for-log against the filter result.
+// A mismatch aborts the window, so the health score rotates to another
+// endpoint before anything is mutated. This costs a header per block plus
+// receipts for active blocks — paranoia priced accordingly, and off by
+// default. Only the polling paths are covered; streaming mode follows the
+// head over a subscription and is for deployments that already trust
+// their endpoint.
+var (
+	verifyReceipts bool
+
+	receiptMismatches = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "tracker_receipt_mismatches_total",
+		Help: "Number of windows where filtered logs disagreed with block receipts.",
+	})
+)
+
+func initReceiptVerification() {
+	verifyReceipts = os.Getenv("VERIFY_RECEIPTS") == "true"
+	if verifyReceipts {
+		log.Printf("Receipt verification enabled: cross-checking filtered logs against block receipts")
+	}
+}
+
+// logKey identifies one log within a block for set comparison.
+func logKey(txHash common.Hash, index uint) string {
+	return fmt.Sprintf("%s:%d", txHash.Hex(), index)
+}
+
+// verifyWindowReceipts cross-checks one window's filter result. Transport
+// errors come back as plain errors; a provable disagreement between the
+// filter result and the chain's receipts increments the mismatch counter
+// before erroring so faulty providers show up in metrics.
+func verifyWindowReceipts(client *ethclient.Client, contract common.Address, from, to uint64, logs []types.Log) error {
+	if !verifyReceipts {
+		return nil
+	}
+	returned := map[uint64]map[string]bool{}
+	for _, vLog := range logs {
+		if returned[vLog.BlockNumber] == nil {
+			returned[vLog.BlockNumber] = map[string]bool{}
+		}
+		returned[vLog.BlockNumber][logKey(vLog.TxHash, vLog.Index)] = true
+	}
+
+	for block := from; block <= to; block++ {
+		header, err := client.HeaderByNumber(rootCtx, new(big.Int).SetUint64(block))
+		if err != nil {
+			return fmt.Errorf("fetch header %d: %w", block, err)
+		}
+		bloomPositive := types.BloomLookup(header.Bloom, contract)
+		if !bloomPositive {
+			if len(returned[block]) > 0 {
+				receiptMismatches.Inc()
+				return fmt.Errorf("block %d: filter returned %d logs but the header bloom excludes %s",
+					block, len(returned[block]), contract.Hex())
+			}
+			continue
+		}
+		// Bloom positive: false positive, another contract's logs, or ours.
+		// Receipts settle it exactly.
+		receipts, err := client.BlockReceipts(rootCtx, rpc.BlockNumberOrHashWithNumber(rpc.BlockNumber(block)))
+		if err != nil {
+			return fmt.Errorf("fetch receipts %d: %w", block, err)
+		}
+		expected := map[string]bool{}
+		for _, receipt := range receipts {
+			for _, rLog := range receipt.Logs {
+				if rLog.Address != contract || len(rLog.Topics) == 0 {
+					continue
+				}
+				if !topicEnabled(rLog.Topics[0]) {
+					continue
+				}
+				expected[logKey(rLog.TxHash, rLog.Index)] = true
+			}
+		}
+		// With an address filter the filter result is deliberately a subset
+		// of the contract's logs, so only fabrication is provable; without
+		// one, an omission is a mismatch too.
+		if len(filterAddressTopics) == 0 {
+			for key := range expected {
+				if !returned[block][key] {
+					receiptMismatches.Inc()
+					return fmt.Errorf("block %d: receipts contain log %s the filter omitted", block, key)
+				}
+			}
+		}
+		for key := range returned[block] {
+			if !expected[key] {
+				receiptMismatches.Inc()
+				return fmt.Errorf("block %d: filter returned log %s absent from receipts", block, key)
+			}
+		}
+	}
+	return nil
+}
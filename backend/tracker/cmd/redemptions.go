@@ -0,0 +1,136 @@
+package main
+
+import (
+	"log"
+	"math/big"
+	"net/http"
+	"strings"
+	"unicode"
+
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// Every TokensBurned event is a redemption request: the data payload carries
+// the BTC address the burned WBTC should be paid out to. Recording them in
+// their own table gives the BTC payout service an ETH-side queue to consume
+// via GET /redemptions.
+
+// redemptionConfirmations is how many confirmations a burn needs before the
+// redemption is reported as confirmed rather than pending.
+func redemptionConfirmations() uint64 {
+	return envUint("REDEMPTION_CONFIRMATIONS", 6)
+}
+
+// decodeBTCTarget extracts the destination bitcoin address from the burn's
+// data payload, which the bridge emits as the address's ASCII bytes. Payloads
+// with non-printable bytes are rejected rather than stored as garbage.
+func decodeBTCTarget(data []byte) string {
+	target := strings.TrimRight(string(data), "\x00")
+	for _, r := range target {
+		if r > unicode.MaxASCII || !unicode.IsPrint(r) {
+			return ""
+		}
+	}
+	return target
+}
+
+// recordRedemption persists one burn as a redemption request. Replayed logs
+// hit the primary key and are ignored, same as the transfer ledger. Targets
+// that decode but fail checksum validation are stored as empty — the payout
+// side must never be handed a string that merely looks like an address — and
+// logged with the raw value for the operator to investigate.
+func recordRedemption(vLog types.Log, from string, amount *big.Int, data []byte) {
+	target := decodeBTCTarget(data)
+	if target == "" {
+		log.Printf("TokensBurned log (tx %s, index %d) has no decodable BTC target", vLog.TxHash.Hex(), vLog.Index)
+	} else if !validBTCAddress(target) {
+		log.Printf("TokensBurned log (tx %s, index %d) carries %q, which is not a valid BTC address; recording the redemption without a target", vLog.TxHash.Hex(), vLog.Index, target)
+		target = ""
+	}
+	_, err := db.Exec(q(`INSERT INTO redemptions (tx_hash, log_index, block, from_address, btc_address, amount)
+		VALUES (?, ?, ?, ?, ?, ?) ON CONFLICT(tx_hash, log_index) DO NOTHING`),
+		vLog.TxHash.Hex(), vLog.Index, vLog.BlockNumber, from, target, amount.String())
+	if err != nil {
+		log.Printf("Failed to record redemption (tx %s, index %d): %v", vLog.TxHash.Hex(), vLog.Index, err)
+	}
+}
+
+// handleRedemptions serves GET /redemptions?status=&limit=: recent burns with
+// their BTC targets, newest first. A redemption is confirmed once its block
+// has redemptionConfirmations() confirmations, pending before that.
+func handleRedemptions(w http.ResponseWriter, r *http.Request) {
+	trackerStatus.Lock()
+	latest := trackerStatus.latestBlock
+	trackerStatus.Unlock()
+	confirmedBelow := uint64(0)
+	if conf := redemptionConfirmations(); latest > conf {
+		confirmedBelow = latest - conf
+	}
+
+	limit := envQueryUint(r, "limit", 100)
+	if limit > 1000 {
+		limit = 1000
+	}
+
+	query := `SELECT tx_hash, log_index, block, from_address, btc_address, amount
+		FROM redemptions`
+	args := []interface{}{}
+	switch status := r.URL.Query().Get("status"); status {
+	case "":
+	case "pending":
+		query += " WHERE block > ?"
+		args = append(args, confirmedBelow)
+	case "confirmed":
+		query += " WHERE block <= ?"
+		args = append(args, confirmedBelow)
+	default:
+		http.Error(w, "status must be pending or confirmed", http.StatusBadRequest)
+		return
+	}
+	query += " ORDER BY block DESC, log_index DESC LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := readDB.Query(q(query), args...)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	type redemption struct {
+		TxHash     string `json:"tx_hash"`
+		LogIndex   uint   `json:"log_index"`
+		Block      uint64 `json:"block"`
+		Timestamp  uint64 `json:"timestamp,omitempty"`
+		From       string `json:"from"`
+		BTCAddress string `json:"btc_address"`
+		Amount     string `json:"amount"`
+		Status     string `json:"status"`
+	}
+	redemptions := []redemption{}
+	blocks := []uint64{}
+	for rows.Next() {
+		var item redemption
+		if err := rows.Scan(&item.TxHash, &item.LogIndex, &item.Block, &item.From, &item.BTCAddress, &item.Amount); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		item.Status = "pending"
+		if item.Block <= confirmedBelow {
+			item.Status = "confirmed"
+		}
+		redemptions = append(redemptions, item)
+		blocks = append(blocks, item.Block)
+	}
+	if err := rows.Err(); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	times := storedBlockTimes(blocks)
+	for i := range redemptions {
+		redemptions[i].Timestamp = times[redemptions[i].Block]
+	}
+	writeJSON(w, map[string]interface{}{
+		"redemptions": redemptions,
+	})
+}
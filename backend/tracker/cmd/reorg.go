@@ -0,0 +1,200 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// Reorg handling. The tracker keeps the canonical hash of every range it
+// commits (processed_blocks, pruned beyond REORG_RETENTION blocks). Each
+// iteration re-checks the newest stored hash against the chain; a mismatch
+// means our position was orphaned. We then walk the stored hashes backwards
+// to the newest block still on the canonical chain and roll back past it:
+// every ledger row above the ancestor has its balance deltas reversed and is
+// deleted, the checkpoint moves back, and the loop reprocesses the canonical
+// blocks on its next pass. Without the transfer ledger the deltas cannot be
+// reversed, so the indexer halts for an operator instead of guessing.
+
+var reorgRetention uint64
+
+func initReorg() {
+	reorgRetention = envUint("REORG_RETENTION", 256)
+}
+
+// recordProcessedRange stores the canonical hash for a committed range end,
+// both as the admin-visible checkpoint hash and as a rollback waypoint, and
+// prunes waypoints older than the retention horizon. Failures are non-fatal;
+// the hashes just lag until the next window.
+func recordProcessedRange(client *ethclient.Client, block uint64) {
+	header, err := client.HeaderByNumber(context.Background(), new(big.Int).SetUint64(block))
+	if err != nil {
+		log.Printf("Failed to fetch header for block %d: %v", block, err)
+		return
+	}
+	hash := header.Hash().Hex()
+	_, err = db.Exec(q(`INSERT INTO tracker_state (key, value) VALUES (?, ?)
+		ON CONFLICT(key) DO UPDATE SET value = excluded.value`),
+		"last_processed_hash", hash)
+	if err != nil {
+		log.Printf("Failed to save checkpoint hash: %v", err)
+	}
+	_, err = db.Exec(q(`INSERT INTO processed_blocks (block, hash) VALUES (?, ?)
+		ON CONFLICT(block) DO UPDATE SET hash = excluded.hash`), block, hash)
+	if err != nil {
+		log.Printf("Failed to record processed hash for block %d: %v", block, err)
+		return
+	}
+	if block > reorgRetention {
+		db.Exec(q(`DELETE FROM processed_blocks WHERE block < ?`), block-reorgRetention)
+		pruneProcessedEvents(block - reorgRetention)
+	}
+}
+
+// detectReorg compares stored range hashes against the canonical chain.
+// It returns reorged=false when the newest stored hash still matches. On a
+// mismatch it walks older waypoints for the newest block both sides agree
+// on; ok=false means even the oldest retained waypoint is orphaned and the
+// reorg is deeper than REORG_RETENTION.
+func detectReorg(client *ethclient.Client) (ancestor uint64, ok bool, reorged bool) {
+	rows, err := readDB.Query(`SELECT block, hash FROM processed_blocks ORDER BY block DESC`)
+	if err != nil {
+		log.Printf("Failed to read processed hashes: %v", err)
+		return 0, false, false
+	}
+	defer rows.Close()
+	type waypoint struct {
+		block uint64
+		hash  string
+	}
+	var waypoints []waypoint
+	for rows.Next() {
+		var wp waypoint
+		if err := rows.Scan(&wp.block, &wp.hash); err != nil {
+			log.Printf("Failed to scan processed hash: %v", err)
+			return 0, false, false
+		}
+		waypoints = append(waypoints, wp)
+	}
+	if len(waypoints) == 0 {
+		return 0, false, false
+	}
+	for i, wp := range waypoints {
+		header, err := client.HeaderByNumber(context.Background(), new(big.Int).SetUint64(wp.block))
+		if err != nil {
+			// Can't tell either way; treat as no reorg and retry next pass.
+			logDeduped("Failed to fetch header for reorg check", err)
+			return 0, false, false
+		}
+		if header.Hash().Hex() == wp.hash {
+			if i == 0 {
+				clearLogDedupe("Failed to fetch header for reorg check")
+				return 0, false, false
+			}
+			return wp.block, true, true
+		}
+	}
+	return 0, false, true
+}
+
+// rollbackToBlock reverses everything recorded above the ancestor block and
+// moves the checkpoint back to it. Returns the block the loop should resume
+// from, which is unchanged when rollback is impossible.
+func rollbackToBlock(ancestor, current uint64) uint64 {
+	if !ledgerEnabled {
+		reason := fmt.Sprintf("reorg orphaned blocks %d-%d but the transfer ledger is disabled; balances cannot be rolled back — rebuild from a snapshot or replay (TRACK_TRANSFERS avoids this)", ancestor+1, current)
+		dispatchNotification("reorg", notifyToken(), reason, nil)
+		pauseIndexing(reason)
+		return current
+	}
+
+	rows, err := readDB.Query(q(`SELECT event, from_address, to_address, value, block FROM transfers
+		WHERE block > ? ORDER BY block DESC, log_index DESC`), ancestor)
+	if err != nil {
+		log.Printf("Failed to read orphaned transfers: %v", err)
+		return current
+	}
+	type orphan struct {
+		event, from, to string
+		value           string
+		block           uint64
+	}
+	var orphans []orphan
+	for rows.Next() {
+		var o orphan
+		if err := rows.Scan(&o.event, &o.from, &o.to, &o.value, &o.block); err != nil {
+			rows.Close()
+			log.Printf("Failed to scan orphaned transfer: %v", err)
+			return current
+		}
+		orphans = append(orphans, o)
+	}
+	rows.Close()
+
+	for _, o := range orphans {
+		// Only Transfer rows moved balances when applied (burns are recorded
+		// but their balance change arrives as a separate Transfer), and
+		// self-transfers were skipped; the reversal mirrors both rules.
+		if o.event != "Transfer" || o.from == o.to {
+			continue
+		}
+		value, ok := new(big.Int).SetString(o.value, 10)
+		if !ok {
+			log.Printf("Corrupt ledger value %q during rollback; skipping row", o.value)
+			continue
+		}
+		if sinkSideTracked(o.from) {
+			updateBalance(o.from, value, ancestor)
+		}
+		if sinkSideTracked(o.to) {
+			updateBalance(o.to, new(big.Int).Neg(value), ancestor)
+		}
+		// Swapped from/to reverses an orphaned mint or burn in the running
+		// supply counter.
+		noteSupplyChange(o.to, o.from, value, o.block)
+	}
+
+	for _, stmt := range []string{
+		`DELETE FROM transfers WHERE block > ?`,
+		`DELETE FROM redemptions WHERE block > ?`,
+		`DELETE FROM mints WHERE block > ?`,
+		`DELETE FROM decode_failures WHERE block > ?`,
+		`DELETE FROM processed_blocks WHERE block > ?`,
+		`DELETE FROM processed_events WHERE block > ?`,
+	} {
+		if _, err := db.Exec(q(stmt), ancestor); err != nil {
+			log.Printf("Rollback cleanup failed (%s): %v", stmt, err)
+		}
+	}
+	if historyEnabled {
+		if _, err := db.Exec(q(`DELETE FROM balance_history WHERE block > ?`), ancestor); err != nil {
+			log.Printf("Rollback cleanup failed (balance_history): %v", err)
+		}
+	}
+	if snapshotInterval > 0 {
+		if _, err := db.Exec(q(`DELETE FROM balance_snapshots WHERE block > ?`), ancestor); err != nil {
+			log.Printf("Rollback cleanup failed (balance_snapshots): %v", err)
+		}
+	}
+	saveCheckpoint(ancestor)
+
+	detail := fmt.Sprintf("rolled back %d orphaned events, blocks %d-%d reprocess from the canonical chain", len(orphans), ancestor+1, current)
+	dispatchNotification("reorg", notifyToken(), detail, nil)
+	log.Printf("Reorg: %s", detail)
+	return ancestor
+}
+
+// handleDetectedReorg is the loop's entry point once detectReorg reports a
+// mismatch.
+func handleDetectedReorg(ancestor uint64, ok bool, current uint64) uint64 {
+	if !ok {
+		reason := fmt.Sprintf("reorg deeper than the %d retained block hashes; cannot locate a common ancestor — rebuild from a snapshot or replay", reorgRetention)
+		dispatchNotification("reorg", notifyToken(), reason, nil)
+		pauseIndexing(reason)
+		return current
+	}
+	return rollbackToBlock(ancestor, current)
+}
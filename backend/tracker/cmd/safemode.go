@@ -0,0 +1,98 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"os"
+	"sync"
+)
+
+// When an integrity check finds a serious divergence, continuing to index
+// risks compounding corruption. With HALT_ON_INTEGRITY_FAILURE=true the
+// indexer pauses itself and stays halted until an operator investigates
+// and explicitly resumes via the admin API.
+var (
+	haltOnIntegrityFailure bool
+
+	pauseState struct {
+		sync.Mutex
+		paused bool
+		reason string
+	}
+)
+
+func initSafeMode() {
+	haltOnIntegrityFailure = os.Getenv("HALT_ON_INTEGRITY_FAILURE") == "true"
+}
+
+func pauseIndexing(reason string) {
+	pauseState.Lock()
+	pauseState.paused = true
+	pauseState.reason = reason
+	pauseState.Unlock()
+	log.Printf("Indexing paused: %s", reason)
+}
+
+func resumeIndexing() {
+	pauseState.Lock()
+	pauseState.paused = false
+	pauseState.reason = ""
+	pauseState.Unlock()
+	log.Printf("Indexing resumed by operator")
+}
+
+func indexingPaused() (bool, string) {
+	pauseState.Lock()
+	defer pauseState.Unlock()
+	return pauseState.paused, pauseState.reason
+}
+
+// reportIntegrityViolation logs the full diagnostic and, in safe mode,
+// halts the indexer on the spot.
+func reportIntegrityViolation(reason string) {
+	log.Printf("INTEGRITY VIOLATION: %s", reason)
+	dispatchNotification("integrity", notifyToken(), reason, nil)
+	if haltOnIntegrityFailure {
+		pauseIndexing("integrity violation: " + reason)
+	}
+}
+
+// handleStatus serves GET /status: the one-look view of what the indexer
+// is doing, including whether safe-mode halted it and why.
+func handleStatus(w http.ResponseWriter, r *http.Request) {
+	trackerStatus.Lock()
+	processed, latest := trackerStatus.lastProcessedBlock, trackerStatus.latestBlock
+	trackerStatus.Unlock()
+	var lag uint64
+	if latest > processed {
+		lag = latest - processed
+	}
+	paused, reason := indexingPaused()
+	state := trackerMode()
+	if paused {
+		state = "halted"
+	}
+	writeJSON(w, map[string]interface{}{
+		"status":               state,
+		"halted":               paused,
+		"halt_reason":          reason,
+		"last_processed_block": processed,
+		"latest_block":         latest,
+		"lag":                  lag,
+		"tolerances":           configuredTolerances(),
+	})
+}
+
+// handleAdminResume serves POST /admin/resume to clear a safe-mode halt
+// after investigation.
+func handleAdminResume(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	resumeIndexing()
+	w.WriteHeader(http.StatusNoContent)
+}
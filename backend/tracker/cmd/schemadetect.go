@@ -0,0 +1,129 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"math/big"
+	"strings"
+	"sync"
+)
+
+// The migration to TEXT big-integer balances rolls out one deployment at a
+// time, so the same binary must work against both schemas. At startup we
+// inspect the balance column's declared type and adapt: numeric columns keep
+// the single-statement SQL arithmetic, TEXT columns get a read-modify-write
+// in Go with big-integer parsing (serialized by a mutex, since the database
+// can no longer do the addition atomically). The active mode is logged so an
+// operator can confirm which path a given instance took.
+//
+// Fresh databases are created with TEXT columns (see the core migration), so full
+// uint256 amounts store exactly. Numeric deployments keep working for values
+// that fit int64; a delta beyond that raises an integrity violation instead
+// of truncating (see updateBalance).
+var (
+	balanceTextStorage bool
+	balanceTextMu      sync.Mutex
+)
+
+// balanceColumnType returns the declared type of wbtc_holders.balance.
+func balanceColumnType() (string, error) {
+	if dbType == "postgres" {
+		var columnType string
+		err := db.QueryRow(`SELECT data_type FROM information_schema.columns
+			WHERE table_name = 'wbtc_holders' AND column_name = 'balance'`).Scan(&columnType)
+		return columnType, err
+	}
+	rows, err := db.Query(`PRAGMA table_info(wbtc_holders)`)
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var cid int
+		var name, columnType string
+		var notNull, pk int
+		var dflt interface{}
+		if err := rows.Scan(&cid, &name, &columnType, &notNull, &dflt, &pk); err != nil {
+			return "", err
+		}
+		if name == "balance" {
+			return columnType, nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return "", err
+	}
+	return "", fmt.Errorf("wbtc_holders has no balance column")
+}
+
+func detectBalanceStorage() {
+	columnType, err := balanceColumnType()
+	if err != nil {
+		log.Fatalf("Failed to detect balance column type: %v", err)
+	}
+	upper := strings.ToUpper(columnType)
+	balanceTextStorage = strings.Contains(upper, "TEXT") || strings.Contains(upper, "CHAR")
+	if balanceTextStorage {
+		log.Printf("Balance storage: TEXT (%s), using Go big-integer read-modify-write", columnType)
+	} else {
+		log.Printf("Balance storage: numeric (%s), using SQL arithmetic", columnType)
+	}
+}
+
+// amountColumnDef is the definition new amount columns are created with,
+// matched to the balance schema so one deployment never mixes numeric and
+// text amount storage: TEXT on fresh databases, INTEGER when joining an
+// existing numeric deployment. Only valid after detectBalanceStorage.
+func amountColumnDef() string {
+	if balanceTextStorage {
+		return "TEXT NOT NULL DEFAULT '0'"
+	}
+	return "INTEGER NOT NULL DEFAULT 0"
+}
+
+// balanceExpr is a SQL expression for the balance column usable in numeric
+// comparisons, ordering and counts under either schema. The cast is exact on
+// postgres (NUMERIC is arbitrary precision); sqlite falls back to float
+// beyond 2^53, which the filtering and ordering call sites accept. Anything
+// that must be exact accumulates big integers in Go instead of summing in
+// SQL.
+func balanceExpr() string {
+	if balanceTextStorage {
+		return "CAST(balance AS NUMERIC)"
+	}
+	return "balance"
+}
+
+// applyBalanceDeltaText is the TEXT-schema counterpart of updateBalance's
+// upsert: read the stored string, add the delta as a big integer, write the
+// string back. The mutex stands in for the atomicity the SQL path gets from
+// the database.
+func applyBalanceDeltaText(address string, delta *big.Int, block uint64) (*big.Int, error) {
+	balanceTextMu.Lock()
+	defer balanceTextMu.Unlock()
+
+	var stored string
+	err := db.QueryRow(q(`SELECT balance FROM wbtc_holders WHERE address = ?`), address).Scan(&stored)
+	current := new(big.Int)
+	switch {
+	case err == nil:
+		if _, ok := current.SetString(stored, 10); !ok {
+			return nil, fmt.Errorf("corrupt balance %q for %s", stored, address)
+		}
+	case err == sql.ErrNoRows:
+		// New holder; current stays zero.
+	default:
+		return nil, err
+	}
+	current.Add(current, delta)
+
+	_, err = db.Exec(q(`INSERT INTO wbtc_holders (address, balance, first_seen_block, last_active_block) VALUES (?, ?, ?, ?)
+		ON CONFLICT(address) DO UPDATE SET balance = excluded.balance,
+			last_active_block = excluded.last_active_block`),
+		address, current.String(), block, block)
+	if err != nil {
+		return nil, err
+	}
+	return current, nil
+}
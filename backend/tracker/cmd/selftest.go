@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"log"
+	"math/big"
+	"os"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// verifyContractCode fails fast when WBTC_ADDRESS has no code — an EOA or an
+// undeployed address would otherwise emit no logs and the indexer would
+// silently report an empty holder set forever. SKIP_CODE_CHECK=true bypasses
+// it for pre-deployment setups where the contract intentionally isn't there
+// yet. An RPC failure only warns: a flaky provider shouldn't block startup.
+func verifyContractCode(client *ethclient.Client, contractAddress common.Address) {
+	if os.Getenv("SKIP_CODE_CHECK") == "true" {
+		return
+	}
+	code, err := client.CodeAt(context.Background(), contractAddress, nil)
+	if err != nil {
+		log.Printf("Could not verify contract code at %s: %v", contractAddress.Hex(), err)
+		return
+	}
+	if len(code) == 0 {
+		log.Fatalf("No contract code at %s: WBTC_ADDRESS is likely an EOA or not deployed on this chain (set SKIP_CODE_CHECK=true to bypass)", contractAddress.Hex())
+	}
+}
+
+// verifyChainID refuses to index when the node is on the wrong network.
+// Pointing a mainnet token's tracker at a testnet endpoint otherwise records
+// garbage without a single error. Unlike the code check this one is fatal on
+// RPC failure too — we cannot prove we are on the right chain, so we don't
+// run. The failover path repeats the check (non-fatally, via
+// checkEndpointChain) for every fallback endpoint before adopting it.
+func verifyChainID(client *ethclient.Client) {
+	expected := os.Getenv("EXPECTED_CHAIN_ID")
+	if expected == "" {
+		return
+	}
+	id, err := client.ChainID(context.Background())
+	if err != nil {
+		log.Fatalf("Failed to fetch chain ID to check against EXPECTED_CHAIN_ID=%s: %v", expected, err)
+	}
+	if id.String() != expected {
+		log.Fatalf("Node reports chain %s but EXPECTED_CHAIN_ID=%s: ETHEREUM_NODE_URL points at the wrong network, refusing to index", id.String(), expected)
+	}
+}
+
+// With a wrong ABI or contract address the indexer "runs" fine and records
+// nothing — the worst failure mode, because nothing errors. The optional
+// startup self-test (DECODE_SELF_TEST=true) fetches a bounded recent range of
+// the contract's logs and checks that at least one decodes cleanly as
+// Transfer, warning loudly when an active token yields zero decodes.
+func runDecodeSelfTest(client *ethclient.Client, contractAddress common.Address) {
+	if os.Getenv("DECODE_SELF_TEST") != "true" {
+		return
+	}
+	span := envUint("DECODE_SELF_TEST_BLOCKS", 1000)
+	latest, err := client.BlockNumber(context.Background())
+	if err != nil {
+		log.Printf("decode self-test skipped: cannot fetch latest block: %v", err)
+		return
+	}
+	from := uint64(0)
+	if latest > span {
+		from = latest - span
+	}
+
+	// No topic filter: we want everything the contract emitted, so a wrong
+	// ABI shows up as "logs exist but none decode" rather than "no logs".
+	query := buildFilterQuery(contractAddress, from, latest)
+	query.Topics = nil
+	logs, err := client.FilterLogs(context.Background(), query)
+	if err != nil {
+		log.Printf("decode self-test skipped: cannot fetch logs %d-%d: %v", from, latest, err)
+		return
+	}
+	if len(logs) == 0 {
+		log.Printf("decode self-test: no logs from %s in blocks %d-%d; token inactive or address wrong", contractAddress.Hex(), from, latest)
+		return
+	}
+
+	transferID := contractABI.Events["Transfer"].ID
+	seen, decoded := 0, 0
+	for _, vLog := range logs {
+		if len(vLog.Topics) == 0 || vLog.Topics[0] != transferID {
+			continue
+		}
+		seen++
+		var transferEvent struct {
+			Value *big.Int
+		}
+		if decodeEvent("Transfer", &transferEvent, vLog) == nil {
+			decoded++
+		}
+	}
+	if decoded == 0 {
+		log.Printf("WARNING: decode self-test failed: %d logs from %s in blocks %d-%d (%d with the Transfer signature) and none decoded — the configured ABI or address is likely wrong",
+			len(logs), contractAddress.Hex(), from, latest, seen)
+		return
+	}
+	log.Printf("decode self-test: %d/%d Transfer logs decoded cleanly in blocks %d-%d", decoded, seen, from, latest)
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// On SIGINT/SIGTERM the root context is cancelled and the indexer gets
+// SHUTDOWN_GRACE (default 10s) to finish and commit the window it is
+// processing, so a routine restart never throws away progress. Cancellation
+// also aborts the in-flight RPC call, so a loop stuck on a slow provider
+// notices immediately instead of at the next poll. Once the loop has drained
+// (or the grace window expires — the abandoned window then replays from the
+// last committed checkpoint on next start), buffered writes are flushed and
+// the database is closed before the process exits. A second signal exits
+// immediately.
+var (
+	// rootCtx is cancelled when shutdown begins; every long-lived loop's
+	// RPC calls and sleeps hang off it.
+	rootCtx, rootCancel = context.WithCancel(context.Background())
+
+	stopRequested atomic.Bool
+
+	loopDrained     = make(chan struct{})
+	loopDrainedOnce sync.Once
+)
+
+func initShutdown() {
+	sigCh := make(chan os.Signal, 2)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		sig := <-sigCh
+		stopRequested.Store(true)
+		rootCancel()
+		log.Printf("Received %s; shutting down", sig)
+		<-sigCh
+		log.Printf("Shutdown: second signal, exiting immediately")
+		os.Exit(1)
+	}()
+}
+
+// confirmLoopDrained is called by the indexing loop once it has committed
+// its position and will not start another window.
+func confirmLoopDrained() {
+	loopDrainedOnce.Do(func() { close(loopDrained) })
+}
+
+// sleepOrShutdown waits for d, returning early once shutdown has been
+// requested so polling loops notice the signal immediately instead of
+// sleeping through it.
+func sleepOrShutdown(d time.Duration) {
+	select {
+	case <-rootCtx.Done():
+	case <-time.After(d):
+	}
+}
+
+// waitForShutdown blocks main until a signal arrives, then runs the orderly
+// teardown: wait for the loop to commit its in-flight window, flush any
+// buffered writes, and close the database.
+func waitForShutdown() {
+	<-rootCtx.Done()
+	grace := envDuration("SHUTDOWN_GRACE", 10*time.Second)
+	exitCode := 0
+	select {
+	case <-loopDrained:
+		log.Printf("Shutdown: in-flight window committed")
+	case <-time.After(grace):
+		log.Printf("Shutdown: grace window %s expired before the window committed; the window replays from the checkpoint on next start", grace)
+		exitCode = 1
+	}
+	if !flushWriteBuffer() {
+		log.Printf("Shutdown: buffered writes could not be committed; they replay from the checkpoint on next start")
+		exitCode = 1
+	}
+	if readDB != nil && readDB != db {
+		readDB.Close()
+	}
+	if db != nil {
+		if err := db.Close(); err != nil {
+			log.Printf("Shutdown: closing database: %v", err)
+		}
+	}
+	log.Printf("Shutdown complete")
+	os.Exit(exitCode)
+}
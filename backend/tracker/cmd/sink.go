@@ -0,0 +1,62 @@
+package main
+
+import (
+	"log"
+	"os"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Bridges that mint and burn through a dedicated treasury/sink contract
+// instead of address(0) need those transfers classified as mint/burn for
+// supply purposes. SINK_ADDRESSES lists such contracts; by default the
+// sink's own side of a transfer is excluded from holder balances (set
+// SINK_TRACK_BALANCES=true to keep it).
+var (
+	zeroAddress = common.Address{}.Hex()
+
+	sinkAddresses     = map[string]bool{}
+	sinkTrackBalances bool
+)
+
+func initSinks() {
+	for _, a := range strings.Split(os.Getenv("SINK_ADDRESSES"), ",") {
+		a = strings.TrimSpace(a)
+		if a == "" {
+			continue
+		}
+		sinkAddresses[common.HexToAddress(a).Hex()] = true
+	}
+	sinkTrackBalances = os.Getenv("SINK_TRACK_BALANCES") == "true"
+	if len(sinkAddresses) > 0 {
+		log.Printf("Treating %d addresses as mint/burn sinks", len(sinkAddresses))
+	}
+}
+
+func isSink(address string) bool {
+	return address == zeroAddress || sinkAddresses[address]
+}
+
+// classifyTransfer labels a transfer by its endpoints: out of a sink (or
+// the zero address) it is a mint, into one it is a burn, otherwise an
+// ordinary holder-to-holder transfer.
+func classifyTransfer(from, to string) string {
+	switch {
+	case isSink(from):
+		return "mint"
+	case isSink(to):
+		return "burn"
+	default:
+		return "transfer"
+	}
+}
+
+// sinkSideTracked reports whether the given side of a transfer should hit
+// the holder balances table.
+func sinkSideTracked(address string) bool {
+	if address == zeroAddress {
+		return false
+	}
+	return sinkTrackBalances || !sinkAddresses[address]
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestSampleHoldersSkipsZeroBalances(t *testing.T) {
+	setupTestDB(t)
+	updateBalance("0x00000000000000000000000000000000000000e1", big.NewInt(100), 1)
+	updateBalance("0x00000000000000000000000000000000000000e2", big.NewInt(7), 1)
+	// Emptied out: must never be sampled.
+	updateBalance("0x00000000000000000000000000000000000000e3", big.NewInt(40), 1)
+	updateBalance("0x00000000000000000000000000000000000000e3", big.NewInt(-40), 2)
+
+	addresses, balances, err := sampleHolders(10)
+	if err != nil {
+		t.Fatalf("sampleHolders: %v", err)
+	}
+	if len(addresses) != 2 || len(balances) != 2 {
+		t.Fatalf("sampled %d addresses, %d balances, want 2 each", len(addresses), len(balances))
+	}
+	seen := map[string]string{}
+	for i, address := range addresses {
+		seen[address] = balances[i].String()
+	}
+	if seen["0x00000000000000000000000000000000000000e1"] != "100" ||
+		seen["0x00000000000000000000000000000000000000e2"] != "7" {
+		t.Errorf("sampled holders = %v", seen)
+	}
+
+	// The limit caps the sample.
+	addresses, _, err = sampleHolders(1)
+	if err != nil {
+		t.Fatalf("sampleHolders: %v", err)
+	}
+	if len(addresses) != 1 {
+		t.Errorf("sampled %d addresses with limit 1", len(addresses))
+	}
+}
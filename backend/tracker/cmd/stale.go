@@ -0,0 +1,75 @@
+package main
+
+import (
+	"log"
+	"os"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// A latestBlock that stops moving means either the chain halted (rare) or
+// the provider is serving stale data (common). Blocks arrive every few
+// seconds, so the default timeout is far above normal cadence: only a real
+// stall trips it, not the ordinary "caught up, waiting for the next block"
+// state.
+var (
+	staleTimeout time.Duration
+
+	headStale = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "tracker_head_stale",
+		Help: "1 when latestBlock has not advanced within LATEST_STALE_TIMEOUT.",
+	})
+
+	headWatch struct {
+		lastValue  uint64
+		lastChange time.Time
+		lastAlert  time.Time
+	}
+)
+
+func envDuration(name string, def time.Duration) time.Duration {
+	v := os.Getenv(name)
+	if v == "" {
+		return def
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		log.Fatalf("Invalid %s %q: %v", name, v, err)
+	}
+	return d
+}
+
+func initStaleDetection() {
+	staleTimeout = envDuration("LATEST_STALE_TIMEOUT", 5*time.Minute)
+}
+
+// noteHead is called from the loop with each observed head. When the value
+// hasn't changed for longer than the timeout it raises the stale gauge and
+// logs an error, rate-limited to one line per timeout window. A stale head
+// also counts against the active RPC endpoint's health score: a provider
+// serving frozen data without erroring is as unusable as one that is down,
+// and only the failure counter can rotate away from it.
+func noteHead(latest uint64) {
+	now := time.Now()
+	if latest != headWatch.lastValue {
+		headWatch.lastValue = latest
+		headWatch.lastChange = now
+		headStale.Set(0)
+		return
+	}
+	if staleTimeout <= 0 || headWatch.lastChange.IsZero() {
+		return
+	}
+	stalled := now.Sub(headWatch.lastChange)
+	if stalled < staleTimeout {
+		return
+	}
+	headStale.Set(1)
+	noteRPCFailure()
+	if now.Sub(headWatch.lastAlert) >= staleTimeout {
+		headWatch.lastAlert = now
+		log.Printf("ERROR: latestBlock frozen at %d for %s (chain halt or stale provider)", latest, stalled.Round(time.Second))
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// cachedStat wraps an expensive computed statistic. The value refreshes on a
+// timer as before, but a large balance mutation can also mark it dirty so
+// the next request recomputes instead of serving a confusingly stale value.
+// minInterval bounds how often recomputation can happen so a burst of big
+// transfers doesn't thrash the query.
+type cachedStat struct {
+	mu          sync.Mutex
+	ttl         time.Duration
+	minInterval time.Duration
+	compute     func() (interface{}, error)
+	value       interface{}
+	computedAt  time.Time
+	dirty       bool
+}
+
+var (
+	statRegistry   []*cachedStat
+	statRegistryMu sync.Mutex
+
+	// Balance deltas at or above this magnitude mark the computed stats
+	// dirty. STATS_INVALIDATE_THRESHOLD, in base units.
+	statsInvalidateThreshold int64
+)
+
+func newCachedStat(ttl, minInterval time.Duration, compute func() (interface{}, error)) *cachedStat {
+	c := &cachedStat{ttl: ttl, minInterval: minInterval, compute: compute}
+	statRegistryMu.Lock()
+	statRegistry = append(statRegistry, c)
+	statRegistryMu.Unlock()
+	return c
+}
+
+func (c *cachedStat) get() (interface{}, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stale := c.value == nil || c.dirty || time.Since(c.computedAt) > c.ttl
+	if stale && (c.value == nil || time.Since(c.computedAt) >= c.minInterval) {
+		value, err := c.compute()
+		if err != nil {
+			if c.value == nil {
+				return nil, err
+			}
+			return c.value, nil // keep serving the previous value on error
+		}
+		c.value = value
+		c.computedAt = time.Now()
+		c.dirty = false
+	}
+	return c.value, nil
+}
+
+func (c *cachedStat) invalidate() {
+	c.mu.Lock()
+	c.dirty = true
+	c.mu.Unlock()
+}
+
+// invalidateStatsForDelta is called from the balance write path; deltas
+// below the threshold leave the timer-based refresh in charge.
+func invalidateStatsForDelta(delta *big.Int) {
+	if new(big.Int).Abs(delta).Cmp(big.NewInt(statsInvalidateThreshold)) < 0 {
+		return
+	}
+	statRegistryMu.Lock()
+	caches := statRegistry
+	statRegistryMu.Unlock()
+	for _, c := range caches {
+		c.invalidate()
+	}
+}
+
+var whalesStat *cachedStat
+
+func initStatCache() {
+	statsInvalidateThreshold = int64(envUint("STATS_INVALIDATE_THRESHOLD", 100000000)) // 1 WBTC
+	whalesStat = newCachedStat(5*time.Minute, 30*time.Second, computeWhales)
+}
+
+type whaleEntry struct {
+	Address string `json:"address"`
+	Balance string `json:"balance"`
+}
+
+func computeWhales() (interface{}, error) {
+	expr := balanceExpr()
+	rows, err := readDB.Query("SELECT address, balance FROM wbtc_holders WHERE " + expr + " > 0 ORDER BY " + expr + " DESC LIMIT 10")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	whales := []whaleEntry{}
+	for rows.Next() {
+		var e whaleEntry
+		if err := rows.Scan(&e.Address, &e.Balance); err != nil {
+			return nil, err
+		}
+		whales = append(whales, e)
+	}
+	return whales, rows.Err()
+}
+
+func handleStatsWhales(w http.ResponseWriter, r *http.Request) {
+	value, err := whalesStat.get()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(value)
+}
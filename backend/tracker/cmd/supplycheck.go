@@ -0,0 +1,212 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Total supply is the invariant a bridge tracker exists to watch: indexed
+// mints minus burns must equal the contract's totalSupply(). The handlers
+// feed a running supply counter from zero-address Transfers — the ERC-20
+// form of mint and burn; TokensMinted/TokensBurned are the bridge-side
+// record of the same moves and deliberately do not feed the counter twice —
+// and a background check compares it against totalSupply() at the
+// checkpoint block every SUPPLY_CHECK_INTERVAL (default 10m, 0 disables).
+// Drift beyond TOLERANCE_SUPPLY on two consecutive checks logs an error
+// and dispatches a supply_mismatch notification.
+//
+// A tracker that starts mid-history cannot derive absolute supply from
+// events alone, so the counter seeds itself from totalSupply() at the
+// checkpoint on its first successful check; events at or below the seed
+// block are already inside the seed and are skipped.
+
+const supplyCallABI = `[
+	{"constant":true,"inputs":[],"name":"totalSupply","outputs":[{"name":"","type":"uint256"}],"stateMutability":"view","type":"function"}
+]`
+
+var supplyState struct {
+	sync.Mutex
+	seeded    bool
+	seedBlock uint64
+	running   big.Int
+	// Last completed reconciliation, surfaced by /bridge/health.
+	lastChecked    time.Time
+	lastCheckBlock uint64
+	lastOnChain    big.Int
+	lastDiff       big.Int
+	lastMisses     int
+}
+
+var supplyMismatches = promauto.NewCounter(prometheus.CounterOpts{
+	Name: "tracker_supply_mismatches_total",
+	Help: "Number of supply reconciliation checks that found drift beyond TOLERANCE_SUPPLY.",
+})
+
+func initSupplyCheck() {
+	var stored string
+	err := db.QueryRow(q("SELECT value FROM tracker_state WHERE key = ?"), "running_supply").Scan(&stored)
+	if err == sql.ErrNoRows {
+		return
+	}
+	if err != nil {
+		log.Fatalf("Failed to load running supply: %v", err)
+	}
+	if _, ok := supplyState.running.SetString(stored, 10); !ok {
+		log.Fatalf("Corrupt running supply %q", stored)
+	}
+	var seed string
+	if err := db.QueryRow(q("SELECT value FROM tracker_state WHERE key = ?"), "running_supply_seed_block").Scan(&seed); err != nil {
+		log.Fatalf("Failed to load running supply seed block: %v", err)
+	}
+	block, err := strconv.ParseUint(seed, 10, 64)
+	if err != nil {
+		log.Fatalf("Corrupt running supply seed block %q: %v", seed, err)
+	}
+	supplyState.seedBlock = block
+	supplyState.seeded = true
+}
+
+// noteSupplyChange adjusts the running supply for a transfer touching the
+// zero address; holder-to-holder transfers leave supply alone. The reorg
+// rollback passes from and to swapped, which turns an orphaned mint into a
+// burn and keeps the counter aligned with the reversed balances.
+func noteSupplyChange(from, to string, amount *big.Int, block uint64) {
+	if from != zeroAddress && to != zeroAddress {
+		return
+	}
+	supplyState.Lock()
+	defer supplyState.Unlock()
+	if !supplyState.seeded || block <= supplyState.seedBlock {
+		return
+	}
+	if from == zeroAddress {
+		supplyState.running.Add(&supplyState.running, amount)
+	} else {
+		supplyState.running.Sub(&supplyState.running, amount)
+	}
+	persistRunningSupplyLocked()
+}
+
+func persistRunningSupplyLocked() {
+	_, err := db.Exec(q(`INSERT INTO tracker_state (key, value) VALUES (?, ?)
+		ON CONFLICT(key) DO UPDATE SET value = excluded.value`),
+		"running_supply", supplyState.running.String())
+	if err != nil {
+		log.Printf("Failed to persist running supply: %v", err)
+	}
+}
+
+// fetchTotalSupply calls totalSupply() on the token contract as of the
+// given block.
+func fetchTotalSupply(block uint64) (*big.Int, error) {
+	callABI, err := abi.JSON(strings.NewReader(supplyCallABI))
+	if err != nil {
+		return nil, fmt.Errorf("parse supply ABI: %w", err)
+	}
+	data, err := callABI.Pack("totalSupply")
+	if err != nil {
+		return nil, fmt.Errorf("pack totalSupply call: %w", err)
+	}
+	addr := common.HexToAddress(os.Getenv("WBTC_ADDRESS"))
+	result, err := currentEthClient().CallContract(context.Background(), ethereum.CallMsg{To: &addr, Data: data}, new(big.Int).SetUint64(block))
+	if err != nil {
+		return nil, fmt.Errorf("call totalSupply(): %w", err)
+	}
+	out, err := callABI.Unpack("totalSupply", result)
+	if err != nil {
+		return nil, fmt.Errorf("unpack totalSupply(): %w", err)
+	}
+	supply, ok := out[0].(*big.Int)
+	if !ok {
+		return nil, fmt.Errorf("totalSupply() returned unexpected type %T", out[0])
+	}
+	return supply, nil
+}
+
+// runSupplyChecks is the reconciliation loop, started once from main.
+func runSupplyChecks() {
+	interval := envDuration("SUPPLY_CHECK_INTERVAL", 10*time.Minute)
+	if interval <= 0 {
+		return
+	}
+	misses := 0
+	for {
+		sleepOrShutdown(interval)
+		if stopRequested.Load() {
+			return
+		}
+		checkSupply(&misses)
+	}
+}
+
+// checkSupply compares the running counter against the contract at the
+// checkpoint block. A single mismatched check only warns: the counter and
+// the chain are read at slightly different moments, so a mint committed
+// between the two reads produces a transient diff. Two in a row is real
+// drift and alerts.
+func checkSupply(misses *int) {
+	block := loadCheckpoint()
+	onChain, err := fetchTotalSupply(block)
+	if err != nil {
+		logDeduped("Failed to fetch totalSupply", err)
+		return
+	}
+	clearLogDedupe("Failed to fetch totalSupply")
+
+	supplyState.Lock()
+	defer supplyState.Unlock()
+	if !supplyState.seeded {
+		supplyState.seeded = true
+		supplyState.seedBlock = block
+		supplyState.running.Set(onChain)
+		persistRunningSupplyLocked()
+		_, err := db.Exec(q(`INSERT INTO tracker_state (key, value) VALUES (?, ?)
+			ON CONFLICT(key) DO UPDATE SET value = excluded.value`),
+			"running_supply_seed_block", strconv.FormatUint(block, 10))
+		if err != nil {
+			log.Printf("Failed to persist running supply seed block: %v", err)
+		}
+		log.Printf("Supply check: seeded running supply %s from totalSupply() at block %d", onChain, block)
+		recordSupplyCheckLocked(block, onChain, big.NewInt(0), 0)
+		return
+	}
+	diff := new(big.Int).Sub(&supplyState.running, onChain)
+	if withinTolerance(onChain, &supplyState.running, checkTolerance("supply")) {
+		*misses = 0
+		recordSupplyCheckLocked(block, onChain, diff, 0)
+		return
+	}
+	*misses++
+	recordSupplyCheckLocked(block, onChain, diff, *misses)
+	detail := fmt.Sprintf("running supply %s != totalSupply() %s at block %d (diff %s)", &supplyState.running, onChain, block, diff)
+	log.Printf("WARNING: supply check: %s", detail)
+	if *misses < 2 {
+		return
+	}
+	supplyMismatches.Inc()
+	dispatchNotification("supply_mismatch", notifyToken(), detail, diff.Abs(diff))
+}
+
+// recordSupplyCheckLocked stores the reconciliation outcome for
+// /bridge/health. Callers hold supplyState.
+func recordSupplyCheckLocked(block uint64, onChain, diff *big.Int, misses int) {
+	supplyState.lastChecked = time.Now()
+	supplyState.lastCheckBlock = block
+	supplyState.lastOnChain.Set(onChain)
+	supplyState.lastDiff.Set(diff)
+	supplyState.lastMisses = misses
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestNoteSupplyChangeTracksMintsAndBurns(t *testing.T) {
+	setupTestDB(t)
+	supplyState.Lock()
+	supplyState.seeded = true
+	supplyState.seedBlock = 10
+	supplyState.running.SetInt64(1000)
+	supplyState.Unlock()
+	t.Cleanup(func() {
+		supplyState.Lock()
+		supplyState.seeded = false
+		supplyState.seedBlock = 0
+		supplyState.running.SetInt64(0)
+		supplyState.Unlock()
+	})
+	const holder = "0x0000000000000000000000000000000000000aaa"
+
+	noteSupplyChange(zeroAddress, holder, big.NewInt(50), 11)
+	noteSupplyChange(holder, zeroAddress, big.NewInt(30), 12)
+	// A holder-to-holder transfer does not move supply.
+	noteSupplyChange(holder, "0x0000000000000000000000000000000000000bbb", big.NewInt(500), 13)
+	// A mint at or below the seed block is already inside the seed.
+	noteSupplyChange(zeroAddress, holder, big.NewInt(99), 10)
+
+	supplyState.Lock()
+	got := supplyState.running.String()
+	supplyState.Unlock()
+	if got != "1020" {
+		t.Errorf("running supply = %s, want 1020", got)
+	}
+
+	var stored string
+	if err := db.QueryRow(q("SELECT value FROM tracker_state WHERE key = ?"), "running_supply").Scan(&stored); err != nil {
+		t.Fatalf("read persisted supply: %v", err)
+	}
+	if stored != "1020" {
+		t.Errorf("persisted supply = %s, want 1020", stored)
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Client-side RPC throttling, so a backfill cannot blow through a paid
+// provider's quota. RPC_MAX_PER_SEC caps the request rate with a token
+// bucket — callers queue, they are never refused — and RPC_DAILY_BUDGET
+// caps total requests per UTC day, idling the tracker until midnight once
+// spent. Both default to 0 (off). Enforcement lives in the HTTP transport
+// underneath every dialed client, so no call site can forget the limiter;
+// websocket endpoints dial plainly since subscriptions push data instead
+// of burning per-request quota.
+var rpcThrottle struct {
+	sync.Mutex
+	perSec float64
+	tokens float64
+	last   time.Time
+	budget uint64
+	day    string
+	used   uint64
+	warned bool
+}
+
+var rpcRequests = promauto.NewCounter(prometheus.CounterOpts{
+	Name: "tracker_rpc_requests_total",
+	Help: "Number of JSON-RPC requests issued through the throttled transport.",
+})
+
+func initRPCThrottle() {
+	rpcThrottle.perSec = float64(envUint("RPC_MAX_PER_SEC", 0))
+	rpcThrottle.tokens = rpcThrottle.perSec
+	rpcThrottle.last = time.Now()
+	rpcThrottle.budget = envUint("RPC_DAILY_BUDGET", 0)
+	if rpcThrottleEnabled() {
+		log.Printf("RPC throttling: %v requests/sec, %d/day (0 = unlimited)", rpcThrottle.perSec, rpcThrottle.budget)
+	}
+}
+
+func rpcThrottleEnabled() bool {
+	return rpcThrottle.perSec > 0 || rpcThrottle.budget > 0
+}
+
+// acquireRPCSlot blocks until the limiter grants one request. During
+// shutdown it stops queueing so the final flush isn't held hostage by an
+// exhausted budget.
+func acquireRPCSlot() {
+	for {
+		if stopRequested.Load() {
+			return
+		}
+		rpcThrottle.Lock()
+		now := time.Now()
+		day := now.UTC().Format("2006-01-02")
+		if day != rpcThrottle.day {
+			if rpcThrottle.warned {
+				log.Printf("RPC daily budget reset for %s", day)
+			}
+			rpcThrottle.day = day
+			rpcThrottle.used = 0
+			rpcThrottle.warned = false
+		}
+		if rpcThrottle.budget > 0 && rpcThrottle.used >= rpcThrottle.budget {
+			if !rpcThrottle.warned {
+				rpcThrottle.warned = true
+				log.Printf("RPC daily budget of %d exhausted; holding all RPC until the next UTC day", rpcThrottle.budget)
+			}
+			rpcThrottle.Unlock()
+			time.Sleep(time.Minute)
+			continue
+		}
+		if rpcThrottle.perSec > 0 {
+			rpcThrottle.tokens += now.Sub(rpcThrottle.last).Seconds() * rpcThrottle.perSec
+			if rpcThrottle.tokens > rpcThrottle.perSec {
+				rpcThrottle.tokens = rpcThrottle.perSec
+			}
+			rpcThrottle.last = now
+			if rpcThrottle.tokens < 1 {
+				wait := time.Duration((1 - rpcThrottle.tokens) / rpcThrottle.perSec * float64(time.Second))
+				rpcThrottle.Unlock()
+				time.Sleep(wait)
+				continue
+			}
+			rpcThrottle.tokens--
+		}
+		rpcThrottle.used++
+		rpcThrottle.Unlock()
+		rpcRequests.Inc()
+		return
+	}
+}
+
+type throttledTransport struct {
+	base http.RoundTripper
+}
+
+func (t throttledTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	acquireRPCSlot()
+	return t.base.RoundTrip(req)
+}
+
+// dialRPC dials an endpoint with the throttle installed under the HTTP
+// transport, so every JSON-RPC request through the resulting client counts.
+func dialRPC(url string) (*rpc.Client, error) {
+	if !rpcThrottleEnabled() || !(strings.HasPrefix(url, "http://") || strings.HasPrefix(url, "https://")) {
+		return rpc.Dial(url)
+	}
+	return rpc.DialOptions(context.Background(), url, rpc.WithHTTPClient(&http.Client{
+		Transport: throttledTransport{base: http.DefaultTransport},
+	}))
+}
+
+// dialEthEndpoint is the ethclient form of dialRPC; every place that
+// connects to ETHEREUM_NODE_URL goes through it.
+func dialEthEndpoint(url string) (*ethclient.Client, error) {
+	rpcClient, err := dialRPC(url)
+	if err != nil {
+		return nil, err
+	}
+	return ethclient.NewClient(rpcClient), nil
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAcquireRPCSlotCountsDailyBudget(t *testing.T) {
+	rpcThrottle.Lock()
+	rpcThrottle.perSec = 0
+	rpcThrottle.budget = 5
+	rpcThrottle.day = time.Now().UTC().Format("2006-01-02")
+	rpcThrottle.used = 0
+	rpcThrottle.Unlock()
+	defer func() {
+		rpcThrottle.Lock()
+		rpcThrottle.budget = 0
+		rpcThrottle.used = 0
+		rpcThrottle.Unlock()
+	}()
+
+	for i := 0; i < 3; i++ {
+		acquireRPCSlot()
+	}
+	rpcThrottle.Lock()
+	used := rpcThrottle.used
+	rpcThrottle.Unlock()
+	if used != 3 {
+		t.Errorf("used = %d, want 3", used)
+	}
+}
+
+func TestAcquireRPCSlotResetsBudgetOnNewDay(t *testing.T) {
+	rpcThrottle.Lock()
+	rpcThrottle.perSec = 0
+	rpcThrottle.budget = 5
+	rpcThrottle.day = "1999-01-01"
+	rpcThrottle.used = 5
+	rpcThrottle.Unlock()
+	defer func() {
+		rpcThrottle.Lock()
+		rpcThrottle.budget = 0
+		rpcThrottle.used = 0
+		rpcThrottle.Unlock()
+	}()
+
+	acquireRPCSlot() // must not block: the stored day is long over
+	rpcThrottle.Lock()
+	used := rpcThrottle.used
+	rpcThrottle.Unlock()
+	if used != 1 {
+		t.Errorf("used after day rollover = %d, want 1", used)
+	}
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"math/big"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Shared block→timestamp resolver. Daily stats, timestamp-based queries
+// and balance history all need header timestamps; fetching HeaderByNumber
+// per block per feature would multiply RPC load. The loop prefetches the
+// window it is about to process, every consumer reads through one memoized
+// cache, and a miss falls back to a single header fetch. Old entries are
+// evicted once the cache outgrows TIMESTAMP_CACHE_SIZE. Every timestamp
+// that is fetched is also written through to the block_times table, so the
+// API can stamp events with wall-clock time without touching the node and
+// without refetching after a restart; STORE_TIMESTAMPS=true forces the
+// prefetch for every processed window even when no other feature needs
+// headers.
+var timestampCache struct {
+	sync.Mutex
+	byBlock map[uint64]uint64
+	max     int
+}
+
+var storeTimestamps bool
+
+func initTimestamps() {
+	timestampCache.max = int(envUint("TIMESTAMP_CACHE_SIZE", 100000))
+	storeTimestamps = os.Getenv("STORE_TIMESTAMPS") == "true"
+}
+
+// timestampsNeeded reports whether any enabled feature consumes block
+// timestamps; the loop skips prefetching entirely otherwise.
+func timestampsNeeded() bool {
+	return dailyStatsEnabled || ledgerEnabled || storeTimestamps
+}
+
+// prefetchTimestamps warms the cache for a block range ahead of event
+// processing, so the events of a window cost one header fetch per block at
+// most, in one place, instead of scattered lookups mid-handler.
+func prefetchTimestamps(from, to uint64) {
+	for block := from; block <= to; block++ {
+		if _, err := blockTimestamp(block); err != nil {
+			log.Printf("Failed to prefetch timestamp for block %d: %v", block, err)
+			return
+		}
+	}
+}
+
+func blockTimestamp(block uint64) (uint64, error) {
+	timestampCache.Lock()
+	if timestampCache.byBlock == nil {
+		timestampCache.byBlock = map[uint64]uint64{}
+	}
+	if ts, ok := timestampCache.byBlock[block]; ok {
+		timestampCache.Unlock()
+		return ts, nil
+	}
+	timestampCache.Unlock()
+
+	// The persisted table answers before the node does: after a restart the
+	// already-indexed history resolves without a single header fetch.
+	var stored uint64
+	err := readDB.QueryRow(q(`SELECT ts FROM block_times WHERE block = ?`), block).Scan(&stored)
+	if err == nil {
+		timestampCache.Lock()
+		timestampCache.byBlock[block] = stored
+		evictOldTimestampsLocked()
+		timestampCache.Unlock()
+		return stored, nil
+	}
+	if err != sql.ErrNoRows {
+		log.Printf("Failed to read stored timestamp for block %d: %v", block, err)
+	}
+
+	header, err := currentEthClient().HeaderByNumber(context.Background(), new(big.Int).SetUint64(block))
+	if err != nil {
+		return 0, err
+	}
+	timestampCache.Lock()
+	timestampCache.byBlock[block] = header.Time
+	evictOldTimestampsLocked()
+	timestampCache.Unlock()
+	if _, err := db.Exec(q(`INSERT INTO block_times (block, ts) VALUES (?, ?)
+		ON CONFLICT(block) DO NOTHING`), block, header.Time); err != nil {
+		log.Printf("Failed to store timestamp for block %d: %v", block, err)
+	}
+	return header.Time, nil
+}
+
+// storedBlockTimes resolves a set of blocks against block_times only — no
+// node round-trips — for API handlers stamping their rows. Blocks indexed
+// before timestamp storage existed simply come back absent.
+func storedBlockTimes(blocks []uint64) map[uint64]uint64 {
+	times := map[uint64]uint64{}
+	if len(blocks) == 0 {
+		return times
+	}
+	seen := map[uint64]bool{}
+	placeholders := make([]string, 0, len(blocks))
+	args := make([]interface{}, 0, len(blocks))
+	for _, block := range blocks {
+		if seen[block] {
+			continue
+		}
+		seen[block] = true
+		placeholders = append(placeholders, "?")
+		args = append(args, block)
+	}
+	rows, err := readDB.Query(q(`SELECT block, ts FROM block_times WHERE block IN (`+strings.Join(placeholders, ", ")+`)`), args...)
+	if err != nil {
+		log.Printf("Failed to read stored timestamps: %v", err)
+		return times
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var block, ts uint64
+		if err := rows.Scan(&block, &ts); err != nil {
+			log.Printf("Failed to scan stored timestamp: %v", err)
+			return times
+		}
+		times[block] = ts
+	}
+	return times
+}
+
+// evictOldTimestampsLocked drops the oldest half of the cache when it
+// exceeds its bound; recent blocks are the hot ones for every consumer.
+func evictOldTimestampsLocked() {
+	if timestampCache.max <= 0 || len(timestampCache.byBlock) <= timestampCache.max {
+		return
+	}
+	blocks := make([]uint64, 0, len(timestampCache.byBlock))
+	for b := range timestampCache.byBlock {
+		blocks = append(blocks, b)
+	}
+	sort.Slice(blocks, func(i, j int) bool { return blocks[i] < blocks[j] })
+	for _, b := range blocks[:len(blocks)/2] {
+		delete(timestampCache.byBlock, b)
+	}
+}
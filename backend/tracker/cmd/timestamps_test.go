@@ -0,0 +1,39 @@
+package main
+
+import "testing"
+
+func TestStoredBlockTimes(t *testing.T) {
+	setupTestDB(t)
+	for block, ts := range map[uint64]uint64{100: 1700000000, 101: 1700000012} {
+		if _, err := db.Exec(q(`INSERT INTO block_times (block, ts) VALUES (?, ?)`), block, ts); err != nil {
+			t.Fatalf("seed block time: %v", err)
+		}
+	}
+
+	times := storedBlockTimes([]uint64{100, 101, 102, 100})
+	if times[100] != 1700000000 || times[101] != 1700000012 {
+		t.Errorf("stored times = %v", times)
+	}
+	if _, ok := times[102]; ok {
+		t.Errorf("block 102 resolved without a stored timestamp")
+	}
+	if len(storedBlockTimes(nil)) != 0 {
+		t.Errorf("empty input returned entries")
+	}
+}
+
+func TestBlockTimestampReadsStoredTable(t *testing.T) {
+	setupTestDB(t)
+	if _, err := db.Exec(q(`INSERT INTO block_times (block, ts) VALUES (?, ?)`), 555, 1700000999); err != nil {
+		t.Fatalf("seed block time: %v", err)
+	}
+	// ethClient is nil in tests: resolving must succeed from the table
+	// without reaching for the node.
+	ts, err := blockTimestamp(555)
+	if err != nil {
+		t.Fatalf("blockTimestamp: %v", err)
+	}
+	if ts != 1700000999 {
+		t.Errorf("ts = %d, want 1700000999", ts)
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"log"
+	"math/big"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Integrity and reconciliation checks compare big numbers that may
+// legitimately differ by dust — rounding inside bridge flows, for example —
+// while any larger drift is a real problem. Each check gets its own absolute
+// tolerance in base units, configured as TOLERANCE_<CHECK> (e.g.
+// TOLERANCE_BALANCE=2, TOLERANCE_SUPPLY=0). The default is 0: exact.
+var toleranceCheckNames = []string{"balance", "supply"}
+
+var toleranceCache struct {
+	sync.Mutex
+	byCheck map[string]*big.Int
+}
+
+// checkTolerance returns the configured tolerance for a named check.
+func checkTolerance(name string) *big.Int {
+	toleranceCache.Lock()
+	defer toleranceCache.Unlock()
+	if toleranceCache.byCheck == nil {
+		toleranceCache.byCheck = map[string]*big.Int{}
+	}
+	if tolerance, ok := toleranceCache.byCheck[name]; ok {
+		return tolerance
+	}
+	tolerance := big.NewInt(0)
+	if raw := os.Getenv("TOLERANCE_" + strings.ToUpper(name)); raw != "" {
+		parsed, ok := new(big.Int).SetString(raw, 10)
+		if !ok || parsed.Sign() < 0 {
+			log.Fatalf("TOLERANCE_%s must be a non-negative integer in base units, got %q", strings.ToUpper(name), raw)
+		}
+		tolerance = parsed
+	}
+	toleranceCache.byCheck[name] = tolerance
+	return tolerance
+}
+
+// withinTolerance reports whether |a - b| <= tolerance.
+func withinTolerance(a, b, tolerance *big.Int) bool {
+	diff := new(big.Int).Sub(a, b)
+	return diff.Abs(diff).Cmp(tolerance) <= 0
+}
+
+// configuredTolerances is the /status view of every check's tolerance, so
+// an audit reading the endpoint knows what "matches" meant.
+func configuredTolerances() map[string]string {
+	tolerances := make(map[string]string, len(toleranceCheckNames))
+	for _, name := range toleranceCheckNames {
+		tolerances[name] = checkTolerance(name).String()
+	}
+	return tolerances
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestWithinTolerance(t *testing.T) {
+	tests := []struct {
+		a, b, tolerance int64
+		want            bool
+	}{
+		{100, 100, 0, true},
+		{100, 101, 0, false},
+		{100, 102, 2, true},
+		{102, 100, 2, true},
+		{100, 103, 2, false},
+		{-5, 5, 10, true},
+	}
+	for _, tt := range tests {
+		got := withinTolerance(big.NewInt(tt.a), big.NewInt(tt.b), big.NewInt(tt.tolerance))
+		if got != tt.want {
+			t.Errorf("withinTolerance(%d, %d, %d) = %v, want %v", tt.a, tt.b, tt.tolerance, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"math/big"
+	"os"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Zero-value Transfers and self-transfers are economically no-ops, but
+// spam campaigns and exchange wallet churn produce floods of them and each
+// one still costs ledger, daily-stat and idempotency writes. The default
+// keeps indexing them — an audit trail should be complete — while
+// SKIP_ZERO_TRANSFERS and SKIP_SELF_TRANSFERS drop them at dispatch,
+// before any write. A counter per reason keeps the dropped volume visible
+// so "missing" events are explainable.
+var (
+	skipZeroTransfers bool
+	skipSelfTransfers bool
+
+	filteredTransfers = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "tracker_filtered_transfers_total",
+		Help: "Number of transfers dropped by the zero-value and self-transfer filters, by reason.",
+	}, []string{"reason"})
+)
+
+func initTransferFilters() {
+	skipZeroTransfers = os.Getenv("SKIP_ZERO_TRANSFERS") == "true"
+	skipSelfTransfers = os.Getenv("SKIP_SELF_TRANSFERS") == "true"
+}
+
+// filteredTransfer reports whether a decoded transfer should be dropped
+// entirely, counting it when so.
+func filteredTransfer(from, to string, value *big.Int) bool {
+	if skipZeroTransfers && value.Sign() == 0 {
+		filteredTransfers.WithLabelValues("zero_value").Inc()
+		return true
+	}
+	if skipSelfTransfers && from == to {
+		filteredTransfers.WithLabelValues("self_transfer").Inc()
+		return true
+	}
+	return false
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestTransferFiltersDropBeforeAnyWrite(t *testing.T) {
+	initTestABI(t)
+	setupTestDB(t)
+	t.Setenv("TRACK_TRANSFERS", "true")
+	initLedger()
+	defer func() { ledgerEnabled = false }()
+
+	skipZeroTransfers = true
+	skipSelfTransfers = true
+	defer func() {
+		skipZeroTransfers = false
+		skipSelfTransfers = false
+	}()
+
+	const (
+		alice = "0x0000000000000000000000000000000000000a11"
+		bob   = "0x0000000000000000000000000000000000000b0b"
+	)
+	handleTransferEvent(makeTransferLog(t, alice, bob, big.NewInt(0), 1, "0x01", 0))
+	handleTransferEvent(makeTransferLog(t, alice, alice, big.NewInt(20), 2, "0x02", 0))
+
+	if n := holderCount(t); n != 0 {
+		t.Errorf("filtered transfers mutated %d balances", n)
+	}
+	var rows int
+	if err := db.QueryRow("SELECT COUNT(*) FROM transfers").Scan(&rows); err != nil {
+		t.Fatalf("count ledger rows: %v", err)
+	}
+	if rows != 0 {
+		t.Errorf("filtered transfers left %d ledger rows, want none", rows)
+	}
+
+	// With the switches off the same self-transfer still writes its ledger
+	// row (the historical behavior the filters opt out of).
+	skipZeroTransfers = false
+	skipSelfTransfers = false
+	handleTransferEvent(makeTransferLog(t, alice, alice, big.NewInt(20), 3, "0x03", 0))
+	if err := db.QueryRow("SELECT COUNT(*) FROM transfers").Scan(&rows); err != nil {
+		t.Fatalf("count ledger rows: %v", err)
+	}
+	if rows != 1 {
+		t.Errorf("unfiltered self-transfer ledger rows = %d, want 1", rows)
+	}
+}
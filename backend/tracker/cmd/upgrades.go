@@ -0,0 +1,104 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// Proxy upgrade handling. A WBTC_ADDRESS behind an EIP-1967 proxy can swap
+// implementations under us; event layouts may shift with it. Two pieces keep
+// indexing alive across that:
+//
+// Detection: Upgraded(address) logs from the proxy are recorded in
+// contract_upgrades and raised as a critical notification, so an operator
+// knows the moment the implementation changed and can validate the ABI
+// (compare-abis exists for exactly that).
+//
+// Overrides: ABI_OVERRIDES is a JSON list of {"from_block": N, "path":
+// "abi.json"} entries. decodeEvent resolves the ABI by the log's block, so
+// pre- and post-upgrade ranges decode with the layout that was live then.
+// Topics for the enabled events from every override are added to the log
+// filter and dispatch tables, so changed signatures don't silently drop
+// events.
+
+// upgradedTopic is keccak256("Upgraded(address)"), the EIP-1967 event.
+var upgradedTopic = common.HexToHash("0xbc7cd75a20ee27fd9adebab32041f755214dbc6bffa90cc0225b39da2e5c2d3b")
+
+type abiOverride struct {
+	fromBlock uint64
+	abi       abi.ABI
+}
+
+// abiOverrides is sorted ascending by fromBlock.
+var abiOverrides []abiOverride
+
+// handleUpgradedEvent records an implementation change and alerts. Balance
+// state is untouched: the proxy's address and storage are stable, only the
+// code behind it moved.
+func handleUpgradedEvent(vLog types.Log) {
+	implementation := ""
+	if len(vLog.Topics) > 1 {
+		implementation = common.HexToAddress(vLog.Topics[1].Hex()).Hex()
+	}
+	_, err := db.Exec(q(`INSERT INTO contract_upgrades (block, tx_hash, implementation)
+		VALUES (?, ?, ?) ON CONFLICT(block, tx_hash) DO NOTHING`),
+		vLog.BlockNumber, vLog.TxHash.Hex(), implementation)
+	if err != nil {
+		log.Printf("Failed to record contract upgrade at block %d: %v", vLog.BlockNumber, err)
+	}
+	detail := "proxy implementation changed to " + implementation + " — verify the ABI still matches (compare-abis)"
+	dispatchNotification("integrity", notifyToken(), detail, nil)
+	log.Printf("Contract upgrade at block %d: %s", vLog.BlockNumber, detail)
+}
+
+// initABIOverrides loads ABI_OVERRIDES and folds each override's enabled
+// events into the filter and dispatch tables. Must run after initEvents so
+// the enabled set is known.
+func initABIOverrides() {
+	raw := os.Getenv("ABI_OVERRIDES")
+	if raw == "" {
+		return
+	}
+	var entries []struct {
+		FromBlock uint64 `json:"from_block"`
+		Path      string `json:"path"`
+	}
+	if err := json.Unmarshal([]byte(raw), &entries); err != nil {
+		log.Fatalf("Invalid ABI_OVERRIDES: %v", err)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].FromBlock < entries[j].FromBlock })
+	for _, entry := range entries {
+		parsed := loadABIFile(entry.Path)
+		abiOverrides = append(abiOverrides, abiOverride{fromBlock: entry.FromBlock, abi: parsed})
+		for _, name := range enabledEvents {
+			event, ok := parsed.Events[name]
+			if !ok {
+				continue
+			}
+			if _, dup := eventHandlers[event.ID]; dup {
+				continue
+			}
+			eventHandlers[event.ID] = eventHandlers[contractABI.Events[name].ID]
+			enabledTopics = append(enabledTopics, event.ID)
+		}
+		log.Printf("ABI override from block %d: %s", entry.FromBlock, entry.Path)
+	}
+}
+
+// abiForBlock resolves which ABI was live at the given block: the newest
+// override at or below it, or the compiled-in ABI when none applies.
+func abiForBlock(block uint64) abi.ABI {
+	selected := contractABI
+	for _, override := range abiOverrides {
+		if override.fromBlock <= block {
+			selected = override.abi
+		}
+	}
+	return selected
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"runtime"
+	"runtime/debug"
+)
+
+// Build identity for fleet management: /version answers "which build is this
+// instance running" so a rollout can be confirmed across instances. version,
+// commit and buildTime are injected at build time:
+//
+//	go build -ldflags "-X main.version=v1.2.3 -X main.commit=$(git rev-parse --short HEAD) -X main.buildTime=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+//
+// When ldflags are absent (a plain `go run`), the VCS metadata embedded by
+// the toolchain fills in the commit where it can.
+var (
+	version   = "dev"
+	commit    = ""
+	buildTime = ""
+)
+
+func buildInfo() map[string]interface{} {
+	resolvedCommit := commit
+	resolvedTime := buildTime
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range info.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				if resolvedCommit == "" {
+					resolvedCommit = setting.Value
+				}
+			case "vcs.time":
+				if resolvedTime == "" {
+					resolvedTime = setting.Value
+				}
+			}
+		}
+	}
+	return map[string]interface{}{
+		"version":    version,
+		"commit":     resolvedCommit,
+		"build_time": resolvedTime,
+		"go_version": runtime.Version(),
+	}
+}
+
+func logBuildInfo() {
+	info := buildInfo()
+	log.Printf("tracker %s (commit %s, built %s, %s)",
+		info["version"], info["commit"], info["build_time"], info["go_version"])
+}
+
+// handleVersion serves GET /version.
+func handleVersion(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, buildInfo())
+}
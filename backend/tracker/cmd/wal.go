@@ -0,0 +1,145 @@
+package main
+
+import (
+	"log"
+	"math/big"
+	"strconv"
+	"sync"
+)
+
+// A transient database outage used to make every updateBalance and
+// checkpoint write fail while the loop kept spinning. Failed writes now
+// land in a bounded in-memory buffer and are replayed, in order and inside
+// one transaction, once the database recovers. The loop stops processing
+// new blocks while the buffer is full so the in-memory checkpoint never
+// advances past what the buffer can cover.
+type pendingWrite struct {
+	address string
+	delta   *big.Int
+	block   uint64
+}
+
+var writeBuffer struct {
+	sync.Mutex
+	pending           []pendingWrite
+	pendingCheckpoint uint64
+	max               int
+}
+
+func initWriteBuffer() {
+	writeBuffer.max = int(envUint("WRITE_BUFFER_SIZE", 100000))
+}
+
+func enqueuePendingWrite(address string, delta *big.Int, block uint64) {
+	writeBuffer.Lock()
+	writeBuffer.pending = append(writeBuffer.pending, pendingWrite{address, delta, block})
+	n := len(writeBuffer.pending)
+	writeBuffer.Unlock()
+	if n == 1 || n%1000 == 0 {
+		log.Printf("Database write failed; buffering deltas in memory (%d pending)", n)
+	}
+}
+
+func notePendingCheckpoint(block uint64) {
+	writeBuffer.Lock()
+	if block > writeBuffer.pendingCheckpoint {
+		writeBuffer.pendingCheckpoint = block
+	}
+	writeBuffer.Unlock()
+}
+
+func writeBufferFull() bool {
+	writeBuffer.Lock()
+	defer writeBuffer.Unlock()
+	return len(writeBuffer.pending) >= writeBuffer.max
+}
+
+// flushWriteBuffer replays buffered deltas and the pending checkpoint in a
+// single transaction. It returns false while the database is still
+// unavailable; nothing is lost or partially applied in that case. TEXT
+// balance storage cannot add in SQL, so that schema replays through the
+// big-integer read-modify-write path instead.
+func flushWriteBuffer() bool {
+	writeBuffer.Lock()
+	pending := writeBuffer.pending
+	checkpoint := writeBuffer.pendingCheckpoint
+	writeBuffer.Unlock()
+	if len(pending) == 0 && checkpoint == 0 {
+		return true
+	}
+	if balanceTextStorage {
+		return flushWriteBufferText(pending, checkpoint)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return false
+	}
+	for _, p := range pending {
+		// A numeric schema only ever buffers int64-representable deltas:
+		// updateBalance rejects larger ones before they reach the write path.
+		if _, err := tx.Exec(q(`INSERT INTO wbtc_holders (address, balance) VALUES (?, ?)
+			ON CONFLICT(address) DO UPDATE SET balance = wbtc_holders.balance + excluded.balance`),
+			p.address, p.delta.Int64()); err != nil {
+			tx.Rollback()
+			return false
+		}
+	}
+	if checkpoint > 0 {
+		if _, err := tx.Exec(q(`INSERT INTO tracker_state (key, value) VALUES (?, ?)
+			ON CONFLICT(key) DO UPDATE SET value = excluded.value`),
+			"last_processed_block", strconv.FormatUint(checkpoint, 10)); err != nil {
+			tx.Rollback()
+			return false
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return false
+	}
+
+	writeBuffer.Lock()
+	// Only drop what we flushed; writes buffered during the flush stay.
+	writeBuffer.pending = writeBuffer.pending[len(pending):]
+	if writeBuffer.pendingCheckpoint == checkpoint {
+		writeBuffer.pendingCheckpoint = 0
+	}
+	writeBuffer.Unlock()
+	if len(pending) > 0 {
+		log.Printf("Database recovered; flushed %d buffered deltas (checkpoint %d)", len(pending), checkpoint)
+	}
+	return true
+}
+
+// flushWriteBufferText replays buffered deltas row by row through
+// applyBalanceDeltaText, which keeps the arithmetic exact for values beyond
+// int64. Replay order is preserved; a failure part-way leaves the remaining
+// writes buffered for the next pass.
+func flushWriteBufferText(pending []pendingWrite, checkpoint uint64) bool {
+	flushed := 0
+	ok := true
+	for _, p := range pending {
+		if _, err := applyBalanceDeltaText(p.address, p.delta, p.block); err != nil {
+			ok = false
+			break
+		}
+		flushed++
+	}
+	if ok && checkpoint > 0 {
+		if _, err := db.Exec(q(`INSERT INTO tracker_state (key, value) VALUES (?, ?)
+			ON CONFLICT(key) DO UPDATE SET value = excluded.value`),
+			"last_processed_block", strconv.FormatUint(checkpoint, 10)); err != nil {
+			ok = false
+		}
+	}
+
+	writeBuffer.Lock()
+	writeBuffer.pending = writeBuffer.pending[flushed:]
+	if ok && writeBuffer.pendingCheckpoint == checkpoint {
+		writeBuffer.pendingCheckpoint = 0
+	}
+	writeBuffer.Unlock()
+	if ok && flushed > 0 {
+		log.Printf("Database recovered; flushed %d buffered deltas (checkpoint %d)", flushed, checkpoint)
+	}
+	return ok
+}
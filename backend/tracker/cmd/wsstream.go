@@ -0,0 +1,162 @@
+package main
+
+import (
+	"log"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// Streaming mode. With ETHEREUM_WS_URL set, the loop switches from 15-second
+// polling to a log subscription once it has caught up to the head, cutting
+// burn-detection latency from minutes to seconds. Received logs are buffered
+// per block and only dispatched once a newer head confirms the block is
+// complete (log and head subscriptions are separate channels with no ordering
+// guarantee between them, so dispatching on arrival could checkpoint a block
+// whose logs were still in flight). When the subscription drops for any
+// reason the function returns and the caller falls back to the polling path,
+// which re-fetches anything not yet checkpointed — nothing is double-applied
+// because unconfirmed buffered logs are discarded, not dispatched.
+var wsClient *ethclient.Client
+
+// streamLogsAtHead runs the subscription until it drops, is paused, or a
+// reorg is signalled. It returns false when streaming is not configured or
+// could not start, in which case the caller sleeps and polls as before.
+// Streaming follows the head directly, so it only engages when
+// CONFIRMATIONS is zero; a confirmation-lagged tracker keeps polling.
+func streamLogsAtHead(contractAddress common.Address, confirmations uint64, lastProcessed, latest *uint64) bool {
+	wsURL := os.Getenv("ETHEREUM_WS_URL")
+	if wsURL == "" || confirmations != 0 {
+		return false
+	}
+	if wsClient == nil {
+		client, err := ethclient.Dial(wsURL)
+		if err != nil {
+			logDeduped("Failed to connect to WS endpoint", err)
+			return false
+		}
+		clearLogDedupe("Failed to connect to WS endpoint")
+		// The WS endpoint is a second provider; make sure it is the same
+		// network before indexing anything it sends.
+		verifyChainID(client)
+		wsClient = client
+	}
+
+	logsCh := make(chan types.Log, 256)
+	logsSub, err := wsClient.SubscribeFilterLogs(rootCtx, ethereum.FilterQuery{
+		Addresses: []common.Address{contractAddress},
+		Topics:    [][]common.Hash{enabledTopics},
+	}, logsCh)
+	if err != nil {
+		logDeduped("Failed to subscribe to logs", err)
+		wsClient.Close()
+		wsClient = nil
+		return false
+	}
+	defer logsSub.Unsubscribe()
+	headsCh := make(chan *types.Header, 16)
+	headsSub, err := wsClient.SubscribeNewHead(rootCtx, headsCh)
+	if err != nil {
+		logDeduped("Failed to subscribe to heads", err)
+		wsClient.Close()
+		wsClient = nil
+		return false
+	}
+	defer headsSub.Unsubscribe()
+	clearLogDedupe("Failed to subscribe to logs")
+	clearLogDedupe("Failed to subscribe to heads")
+	log.Printf("Streaming logs over %s from block %d", wsURL, *lastProcessed+1)
+
+	pending := map[uint64][]types.Log{}
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case vLog := <-logsCh:
+			if !bufferStreamedLog(vLog, pending, *lastProcessed) {
+				return true
+			}
+		case head := <-headsCh:
+			headNumber := head.Number.Uint64()
+			noteHead(headNumber)
+			*latest = headNumber
+			if headNumber >= 1 && headNumber-1 > *lastProcessed {
+				// The two subscriptions race through this select, so the
+				// head for N+1 can be picked while a log for N is still
+				// queued in logsCh. Drain whatever has already arrived
+				// before flushing; otherwise the checkpoint moves past the
+				// late log's block and it is discarded for good.
+				for drained := false; !drained; {
+					select {
+					case vLog := <-logsCh:
+						if !bufferStreamedLog(vLog, pending, *lastProcessed) {
+							return true
+						}
+					default:
+						drained = true
+					}
+				}
+				prev := *lastProcessed
+				flushStreamedBlocks(pending, prev+1, headNumber-1)
+				*lastProcessed = headNumber - 1
+				saveCheckpoint(*lastProcessed)
+				maybeTakeSnapshot(prev, *lastProcessed)
+				recordProcessedRange(wsClient, *lastProcessed)
+				setTrackerStatus(*lastProcessed, headNumber)
+				updatePositionMetrics(contractAddress.Hex(), tokenSymbol(), *lastProcessed, headNumber)
+			}
+		case err := <-logsSub.Err():
+			log.Printf("Log subscription dropped: %v; falling back to polling", err)
+			wsClient.Close()
+			wsClient = nil
+			return true
+		case err := <-headsSub.Err():
+			log.Printf("Head subscription dropped: %v; falling back to polling", err)
+			wsClient.Close()
+			wsClient = nil
+			return true
+		case <-ticker.C:
+			if stopRequested.Load() {
+				return true
+			}
+			if paused, _ := indexingPaused(); paused {
+				return true
+			}
+		}
+	}
+}
+
+// bufferStreamedLog queues one received log for dispatch. It returns false
+// when the log is a reorg removal, which the caller handles by dropping to
+// polling so the detection and rollback path can deal with it.
+func bufferStreamedLog(vLog types.Log, pending map[uint64][]types.Log, lastProcessed uint64) bool {
+	if vLog.Removed {
+		log.Printf("Streaming saw a removed log at block %d; dropping to polling for reorg handling", vLog.BlockNumber)
+		return false
+	}
+	if vLog.BlockNumber > lastProcessed {
+		pending[vLog.BlockNumber] = append(pending[vLog.BlockNumber], vLog)
+	}
+	return true
+}
+
+// flushStreamedBlocks dispatches the buffered logs for from..to in block and
+// log-index order, mirroring what a FilterLogs window would have produced.
+func flushStreamedBlocks(pending map[uint64][]types.Log, from, to uint64) {
+	for block := from; block <= to; block++ {
+		logs := pending[block]
+		if len(logs) == 0 {
+			continue
+		}
+		sort.Slice(logs, func(i, j int) bool { return logs[i].Index < logs[j].Index })
+		for _, vLog := range logs {
+			dispatchLog(vLog)
+		}
+		delete(pending, block)
+	}
+}